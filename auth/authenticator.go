@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+const defaultClockSkew = time.Minute
+
+var (
+	ErrMissingToken     = errors.New("bearer token is required")
+	ErrInvalidScheme    = errors.New("authorization scheme must be Bearer")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrInvalidAudience  = errors.New("token audience is not accepted")
+	ErrUnknownIssuer    = errors.New("token issuer is not trusted")
+	ErrNoIssuer         = errors.New("no OIDC issuer configured")
+	ErrMissingKeySetURL = errors.New("issuer does not advertise a key set")
+	ErrMissingAudience  = errors.New("issuer has no accepted audience")
+	ErrIDToken          = errors.New("ID tokens are not accepted as access tokens")
+	// ErrForbidden is returned by authorization policies denying a request.
+	ErrForbidden = errors.New("insufficient permissions")
+)
+
+// Authenticator verifies bearer access tokens issued by the trusted issuers. The signing keys
+// of each issuer are fetched from its JWKS endpoint, cached, and refreshed when a token is
+// signed with an unknown key.
+type Authenticator struct {
+	issuers     map[string]*issuer
+	clockSkew   time.Duration
+	roleClaims  []string
+	scopeClaims []string
+	now         func() time.Time
+}
+
+// issuer verifies the tokens of a trusted issuer.
+type issuer struct {
+	verifier  *oidc.IDTokenVerifier
+	audiences []string
+}
+
+// NewAuthenticator creates an authenticator for the issuers of the configuration.
+// Issuers without a configured key set URL are discovered with ctx. Every issuer must accept
+// at least one audience, otherwise tokens issued to any client of the issuer would be accepted.
+func NewAuthenticator(ctx context.Context, cfg config.OidcConfig) (*Authenticator, error) {
+	issuers := cfg.Issuers
+	if cfg.Issuer != "" {
+		audiences := cfg.Audiences
+		if len(audiences) == 0 && cfg.ClientId != "" {
+			audiences = []string{cfg.ClientId}
+		}
+		main := config.OidcIssuerConfig{Issuer: cfg.Issuer, JWKSURL: cfg.JWKSURL, Audiences: audiences, Algorithms: cfg.Algorithms}
+		issuers = append([]config.OidcIssuerConfig{main}, issuers...)
+	}
+	if len(issuers) == 0 {
+		return nil, ErrNoIssuer
+	}
+
+	a := &Authenticator{
+		issuers:     make(map[string]*issuer, len(issuers)),
+		clockSkew:   cfg.ClockSkew,
+		roleClaims:  cfg.RoleClaims,
+		scopeClaims: cfg.ScopeClaims,
+		now:         time.Now,
+	}
+	if a.clockSkew <= 0 {
+		a.clockSkew = defaultClockSkew
+	}
+	if len(a.roleClaims) == 0 {
+		a.roleClaims = defaultRoleClaims
+	}
+	if len(a.scopeClaims) == 0 {
+		a.scopeClaims = defaultScopeClaims
+	}
+	for _, ic := range issuers {
+		if len(ic.Audiences) == 0 {
+			return nil, fmt.Errorf("issuer %s: %w", ic.Issuer, ErrMissingAudience)
+		}
+		iss, err := newIssuer(ctx, ic)
+		if err != nil {
+			return nil, fmt.Errorf("issuer %s: %w", ic.Issuer, err)
+		}
+		a.issuers[ic.Issuer] = iss
+	}
+	return a, nil
+}
+
+// newIssuer creates the verifier of an issuer, discovering its key set and signing
+// algorithms if they are not configured.
+func newIssuer(ctx context.Context, cfg config.OidcIssuerConfig) (*issuer, error) {
+	jwksURL, algorithms := cfg.JWKSURL, cfg.Algorithms
+	if jwksURL == "" {
+		provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		var metadata struct {
+			JWKSURL    string   `json:"jwks_uri"`
+			Algorithms []string `json:"id_token_signing_alg_values_supported"`
+		}
+		if err := provider.Claims(&metadata); err != nil {
+			return nil, err
+		}
+		if metadata.JWKSURL == "" {
+			return nil, ErrMissingKeySetURL
+		}
+		jwksURL = metadata.JWKSURL
+		if len(algorithms) == 0 {
+			algorithms = metadata.Algorithms
+		}
+	}
+	keySet := oidc.NewRemoteKeySet(ctx, jwksURL)
+	return &issuer{
+		// The audience and the validity period are checked by Verify, with the configured clock skew.
+		verifier: oidc.NewVerifier(cfg.Issuer, keySet, &oidc.Config{
+			SkipClientIDCheck:    true,
+			SkipExpiryCheck:      true,
+			SupportedSigningAlgs: algorithms,
+		}),
+		audiences: cfg.Audiences,
+	}, nil
+}
+
+// Authenticate verifies the bearer token of an Authorization header and returns its principal.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization string) (*Principal, error) {
+	token, err := BearerToken(authorization)
+	if err != nil {
+		return nil, err
+	}
+	return a.Verify(ctx, token)
+}
+
+// Verify verifies the signature, issuer, audience and validity period of an access token and
+// returns its principal. ID tokens are rejected with ErrIDToken.
+func (a *Authenticator) Verify(ctx context.Context, token string) (*Principal, error) {
+	typ, iss, err := unverifiedHeader(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	trusted, ok := a.issuers[iss]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownIssuer, iss)
+	}
+	verified, err := trusted.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var claims map[string]any
+	if err := verified.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	now := a.now()
+	if verified.Expiry.IsZero() {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(verified.Expiry.Add(a.clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(a.clockSkew).Before(nbf) {
+		return nil, fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	if !slices.ContainsFunc(verified.Audience, func(aud string) bool {
+		return slices.Contains(trusted.audiences, aud)
+	}) {
+		return nil, ErrInvalidAudience
+	}
+	if isIDToken(typ, claims) {
+		return nil, ErrIDToken
+	}
+	return newPrincipal(claims, a.roleClaims, a.scopeClaims), nil
+}
+
+// isIDToken reports whether a token is an ID token rather than an access token. Access tokens
+// typed "at+jwt" (RFC 9068) are accepted; otherwise tokens are ID tokens if typed "ID", as done
+// by Keycloak, or if they carry claims only found in ID tokens: a nonce, a hash of an
+// authorization code or access token, or an authentication time without granted scopes.
+func isIDToken(typ string, claims map[string]any) bool {
+	typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+	if typ == "at+jwt" {
+		return false
+	}
+	if t, _ := claims["typ"].(string); strings.EqualFold(t, "ID") {
+		return true
+	}
+	for _, claim := range []string{"nonce", "at_hash", "c_hash"} {
+		if _, ok := claims[claim]; ok {
+			return true
+		}
+	}
+	_, authTime := claims["auth_time"]
+	_, scope := claims["scope"]
+	return authTime && !scope
+}
+
+// BearerToken returns the token of an Authorization header using the Bearer scheme.
+// The scheme is case-insensitive.
+func BearerToken(authorization string) (string, error) {
+	authorization = strings.TrimSpace(authorization)
+	if authorization == "" {
+		return "", ErrMissingToken
+	}
+	scheme, token, _ := strings.Cut(authorization, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrInvalidScheme
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrMissingToken
+	}
+	return token, nil
+}
+
+// unverifiedHeader returns the typ header and the iss claim of a token before its signature
+// is verified, to select the issuer verifying it.
+func unverifiedHeader(token string) (typ, iss string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", errors.New("malformed jwt")
+	}
+	var header struct {
+		Type string `json:"typ"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return "", "", fmt.Errorf("malformed jwt header: %w", err)
+	}
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", "", fmt.Errorf("malformed jwt claims: %w", err)
+	}
+	return header.Type, claims.Issuer, nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+// testIssuer signs tokens and serves its key set.
+type testIssuer struct {
+	url    string
+	key    *rsa.PrivateKey
+	server *httptest.Server
+}
+
+func newTestIssuer(t *testing.T, kid string) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": kid,
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+	return &testIssuer{url: server.URL, key: key, server: server}
+}
+
+// sign returns a token with the header and claims, signed with RS256.
+func (i *testIssuer) sign(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	h := map[string]any{"alg": "RS256", "typ": "JWT"}
+	for k, v := range header {
+		h[k] = v
+	}
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signingInput := encode(h) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		authorization string
+		want          string
+		err           error
+	}{
+		{"Bearer abc", "abc", nil},
+		{"bearer abc", "abc", nil},
+		{"  BEARER   abc  ", "abc", nil},
+		{"", "", ErrMissingToken},
+		{"Bearer", "", ErrMissingToken},
+		{"Bearer   ", "", ErrMissingToken},
+		{"Basic dXNlcjpwYXNz", "", ErrInvalidScheme},
+		{"Bearerabc", "", ErrInvalidScheme},
+	}
+	for _, tt := range tests {
+		got, err := BearerToken(tt.authorization)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("BearerToken(%q) = %q, %v; want %q, %v", tt.authorization, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestAuthenticatorVerify(t *testing.T) {
+	main := newTestIssuer(t, "main")
+	other := newTestIssuer(t, "other")
+	a, err := NewAuthenticator(context.Background(), config.OidcConfig{
+		Issuer:   main.url,
+		JWKSURL:  main.url,
+		ClientId: "web",
+		Issuers: []config.OidcIssuerConfig{
+			{Issuer: other.url, JWKSURL: other.url, Audiences: []string{"api"}},
+		},
+		ClockSkew: 30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	a.now = func() time.Time { return now }
+
+	claims := func(iss string, extra map[string]any) map[string]any {
+		c := map[string]any{
+			"iss": iss,
+			"sub": "user-1",
+			"aud": "api",
+			"exp": now.Add(time.Minute).Unix(),
+			"iat": now.Add(-time.Minute).Unix(),
+		}
+		if iss == main.url {
+			c["aud"] = "web"
+			c["azp"] = "spa"
+		}
+		for k, v := range extra {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	valid := main.sign(t, nil, claims(main.url, nil))
+	tampered := valid[:len(valid)-4] + strings.Map(func(r rune) rune { return 'A' + (r+1)%26 }, valid[len(valid)-4:])
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"main issuer", valid, nil},
+		{"other issuer", other.sign(t, nil, claims(other.url, nil)), nil},
+		{"key of another issuer", other.sign(t, nil, claims(main.url, nil)), ErrInvalidToken},
+		{"unknown issuer", other.sign(t, nil, claims("https://unknown.example.com", nil)), ErrUnknownIssuer},
+		{"malformed", "not.a-token", ErrInvalidToken},
+		{"tampered signature", tampered, ErrInvalidToken},
+		{"expired within skew", main.sign(t, nil, claims(main.url, map[string]any{"exp": now.Add(-20 * time.Second).Unix()})), nil},
+		{"expired", main.sign(t, nil, claims(main.url, map[string]any{"exp": now.Add(-time.Minute).Unix()})), ErrTokenExpired},
+		{"missing exp", main.sign(t, nil, claims(main.url, map[string]any{"exp": nil})), ErrInvalidToken},
+		{"not yet valid within skew", main.sign(t, nil, claims(main.url, map[string]any{"nbf": now.Add(20 * time.Second).Unix()})), nil},
+		{"not yet valid", main.sign(t, nil, claims(main.url, map[string]any{"nbf": now.Add(time.Minute).Unix()})), ErrInvalidToken},
+		{"audience of another client", main.sign(t, nil, claims(main.url, map[string]any{"aud": "admin"})), ErrInvalidAudience},
+		{"one of the audiences", other.sign(t, nil, claims(other.url, map[string]any{"aud": []string{"account", "api"}})), nil},
+		{"no audience", other.sign(t, nil, claims(other.url, map[string]any{"aud": nil})), ErrInvalidAudience},
+		{"id token with nonce", main.sign(t, nil, claims(main.url, map[string]any{"nonce": "n"})), ErrIDToken},
+		{"keycloak id token", main.sign(t, nil, claims(main.url, map[string]any{"typ": "ID"})), ErrIDToken},
+		{"id token with at_hash", main.sign(t, nil, claims(main.url, map[string]any{"at_hash": "h"})), ErrIDToken},
+		{"id token with auth_time", main.sign(t, nil, claims(main.url, map[string]any{"auth_time": now.Unix()})), ErrIDToken},
+		{"access token with auth_time", main.sign(t, nil, claims(main.url, map[string]any{"auth_time": now.Unix(), "scope": "openid"})), nil},
+		{"access token for the client", main.sign(t, nil, claims(main.url, map[string]any{"azp": "web"})), nil},
+		{"keycloak access token", main.sign(t, nil, claims(main.url, map[string]any{"azp": "web", "typ": "Bearer", "scope": "profile"})), nil},
+		{"at+jwt access token", main.sign(t, map[string]any{"typ": "at+jwt"}, claims(main.url, map[string]any{"azp": "web"})), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err == nil && principal.Subject != "user-1" {
+				t.Errorf("got subject %q", principal.Subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorAuthenticate(t *testing.T) {
+	iss := newTestIssuer(t, "main")
+	a, err := NewAuthenticator(context.Background(), config.OidcConfig{Issuer: iss.url, JWKSURL: iss.url, Audiences: []string{"api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := iss.sign(t, nil, map[string]any{"iss": iss.url, "sub": "user-1", "aud": "api", "exp": time.Now().Add(time.Minute).Unix()})
+	if _, err := a.Authenticate(context.Background(), "bearer "+token); err != nil {
+		t.Errorf("got %v", err)
+	}
+	if _, err := a.Authenticate(context.Background(), "Token "+token); !errors.Is(err, ErrInvalidScheme) {
+		t.Errorf("got %v, want %v", err, ErrInvalidScheme)
+	}
+}
+
+func TestNewAuthenticatorRequiresAudience(t *testing.T) {
+	tests := []config.OidcConfig{
+		{Issuer: "https://issuer.example.com", JWKSURL: "https://issuer.example.com/jwks"},
+		{Issuer: "https://issuer.example.com", JWKSURL: "https://issuer.example.com/jwks", ClientId: "web",
+			Issuers: []config.OidcIssuerConfig{{Issuer: "https://other.example.com", JWKSURL: "https://other.example.com/jwks"}}},
+	}
+	for _, cfg := range tests {
+		if _, err := NewAuthenticator(context.Background(), cfg); !errors.Is(err, ErrMissingAudience) {
+			t.Errorf("got %v, want %v", err, ErrMissingAudience)
+		}
+	}
+	if _, err := NewAuthenticator(context.Background(), config.OidcConfig{}); !errors.Is(err, ErrNoIssuer) {
+		t.Errorf("got %v, want %v", err, ErrNoIssuer)
+	}
+}
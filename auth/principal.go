@@ -0,0 +1,131 @@
+// Package auth authenticates requests with OIDC bearer tokens.
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	defaultRoleClaims  = []string{"roles", "realm_access.roles"}
+	defaultScopeClaims = []string{"scope", "scp"}
+)
+
+// Principal is the authenticated subject of a request, bound from the claims of its token.
+type Principal struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	Username  string
+	Email     string
+	// Roles and Scopes are read from the role and scope claims of the configuration.
+	Roles  []string
+	Scopes []string
+	// Claims holds all the claims of the token.
+	Claims map[string]any
+}
+
+// HasRole reports whether the principal has the role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal has been granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// ClaimValues returns the values of the claim at a dot-separated path, see ClaimValues.
+func (p *Principal) ClaimValues(path string) []string {
+	return ClaimValues(p.Claims, path)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, if the request was authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// newPrincipal binds the claims of a verified token, reading roles and scopes from the claim paths.
+func newPrincipal(claims map[string]any, roleClaims, scopeClaims []string) *Principal {
+	p := &Principal{
+		Subject:  stringClaim(claims, "sub"),
+		Issuer:   stringClaim(claims, "iss"),
+		Audience: stringsClaim(claims["aud"]),
+		Username: stringClaim(claims, "preferred_username"),
+		Email:    stringClaim(claims, "email"),
+		Claims:   claims,
+	}
+	if exp, ok := numericClaim(claims, "exp"); ok {
+		p.ExpiresAt = exp
+	}
+	for _, path := range roleClaims {
+		p.Roles = append(p.Roles, ClaimValues(claims, path)...)
+	}
+	for _, path := range scopeClaims {
+		p.Scopes = append(p.Scopes, ClaimValues(claims, path)...)
+	}
+	return p
+}
+
+// ClaimValues returns the values of the claim at a dot-separated path, such as
+// "resource_access.my-client.roles". A string claim is split on spaces, as the "scope" claim.
+func ClaimValues(claims map[string]any, path string) []string {
+	var v any = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	if s, ok := v.(string); ok {
+		return strings.Fields(s)
+	}
+	return stringsClaim(v)
+}
+
+// stringClaim returns a string claim, or an empty string.
+func stringClaim(claims map[string]any, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// stringsClaim returns a claim holding a string or a list of strings.
+func stringsClaim(v any) []string {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// numericClaim returns a claim holding a NumericDate.
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	n, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(n), 0), true
+}
@@ -58,6 +58,10 @@ type Cache interface {
 
 	// GetType returns the type of the cache.
 	GetType() string
+
+	// Close releases the underlying connections of the cache.
+	// If an error occurs, it returns the error.
+	Close() error
 }
 
 // InitCache is a function that initializes a cache.
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultOk    = "ok"
+	resultError = "error"
+)
+
+// cacheMetrics records the operations of a cache store.
+type cacheMetrics struct {
+	operations metric.Int64Counter
+	duration   metric.Float64Histogram
+	system     attribute.KeyValue
+}
+
+func newCacheMetrics(system string) *cacheMetrics {
+	meter := observability.InternalMeter("cache")
+	operations, err := meter.Int64Counter("cache.operations",
+		metric.WithUnit("{operation}"),
+		metric.WithDescription("Number of cache operations by result (hit, miss, ok or error)."))
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to create cache metrics", zap.Error(err))
+	}
+	duration, err := meter.Float64Histogram("cache.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of cache operations."))
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to create cache metrics", zap.Error(err))
+	}
+	return &cacheMetrics{operations: operations, duration: duration, system: attribute.String("cache.system", system)}
+}
+
+// record records an operation started at start. Lookups are recorded as a hit or a miss.
+func (m *cacheMetrics) record(ctx context.Context, operation string, lookup bool, start time.Time, err error) {
+	result := resultOk
+	switch {
+	case errors.Is(err, &NotFound{}):
+		result = resultMiss
+	case err != nil:
+		result = resultError
+	case lookup:
+		result = resultHit
+	}
+	attrs := metric.WithAttributes(m.system, attribute.String("cache.operation", operation))
+	m.operations.Add(ctx, 1, attrs, metric.WithAttributes(attribute.String("cache.result", result)))
+	m.duration.Record(ctx, time.Since(start).Seconds(), attrs)
+}
@@ -17,21 +17,23 @@ type Options struct {
 	Tags                      []string
 	ClientSideCacheExpiration time.Duration
 
-	Addrs    string
-	User     string
-	Password string
-	Type     string
-	Enable   bool
+	Addrs     string
+	User      string
+	Password  string
+	Type      string
+	Enable    bool
+	TraceKeys bool
 }
 
 func newOptions(opts ...Option) *Options {
 	cfg := config.GetConfig().Cache
 	opt := &Options{
-		Addrs:    cfg.Addrs,
-		User:     cfg.User,
-		Password: cfg.Password,
-		Type:     cfg.Type,
-		Enable:   cfg.Enable,
+		Addrs:     cfg.Addrs,
+		User:      cfg.User,
+		Password:  cfg.Password,
+		Type:      cfg.Type,
+		Enable:    cfg.Enable,
+		TraceKeys: cfg.TraceKeys,
 	}
 	for _, o := range opts {
 		o(opt)
@@ -70,6 +72,13 @@ func Enable(enable bool) Option {
 	}
 }
 
+// TraceKeys records the keys of cache operations in their spans.
+func TraceKeys(traceKeys bool) Option {
+	return func(o *Options) {
+		o.TraceKeys = traceKeys
+	}
+}
+
 // IsEmpty checks if the Options is empty.
 func (o *Options) IsEmpty() bool {
 	return o.Cost == 0 && o.Expiration == 0 && len(o.Tags) == 0
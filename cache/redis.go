@@ -32,8 +32,9 @@ func initRedisClient(opts *Options) rueidis.Client {
 
 // RedisStore is a Redis implementation of the Cache interface.
 type RedisStore struct {
-	client rueidis.Client
-	opts   *Options
+	client  rueidis.Client
+	opts    *Options
+	metrics *cacheMetrics
 }
 
 // NewRedisStore creates a new RedisStore instance.
@@ -46,27 +47,36 @@ func NewRedisStore(options ...Option) Cache {
 	}
 
 	return &RedisStore{
-		client: client,
-		opts:   opts,
+		client:  client,
+		opts:    opts,
+		metrics: newCacheMetrics(RueidisType),
 	}
 }
 
 // HGet retrieves the value of a hash field.
 func (store *RedisStore) HGet(ctx context.Context, key any, field any, options ...Option) (any, error) {
+	ctx, done := store.observe(ctx, "hget", key, true)
 	cmd := store.client.B().Hget().Key(key.(string)).Field(field.(string)).Cache()
 	res := store.client.DoCache(ctx, cmd, store.opts.ClientSideCacheExpiration)
 	str, err := res.ToString()
 	if rueidis.IsRedisNil(err) {
 		err = NotFoundWithCause(err)
 	}
+	done(err)
 	return str, err
 }
 
 // HGetAll retrieves all the fields and values from a hash.
 func (store *RedisStore) HGetAll(ctx context.Context, key any, options ...Option) (map[string]any, error) {
+	ctx, done := store.observe(ctx, "hgetall", key, true)
 	cmd := store.client.B().Hgetall().Key(key.(string)).Cache()
 	res := store.client.DoCache(ctx, cmd, store.opts.ClientSideCacheExpiration)
 	m, err := res.ToMap()
+	if err == nil && len(m) == 0 {
+		done(NotFoundWithCause(nil))
+	} else {
+		done(err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -81,8 +91,10 @@ func (store *RedisStore) HGetAll(ctx context.Context, key any, options ...Option
 
 // HSet sets the value of a hash field.
 func (store *RedisStore) HSet(ctx context.Context, key any, field any, value any, options ...Option) error {
+	ctx, done := store.observe(ctx, "hset", key, false)
 	cmd := store.client.B().Hset().Key(key.(string)).FieldValue().FieldValue(field.(string), value.(string)).Build()
 	err := store.client.Do(ctx, cmd).Error()
+	done(err)
 	if err != nil {
 		return err
 	}
@@ -102,28 +114,33 @@ func (store *RedisStore) HSet(ctx context.Context, key any, field any, value any
 
 // Get retrieves the value of a key.
 func (store *RedisStore) Get(ctx context.Context, key any) (any, error) {
+	ctx, done := store.observe(ctx, "get", key, true)
 	cmd := store.client.B().Get().Key(key.(string)).Cache()
 	res := store.client.DoCache(ctx, cmd, store.opts.ClientSideCacheExpiration)
 	str, err := res.ToString()
 	if rueidis.IsRedisNil(err) {
 		err = NotFoundWithCause(err)
 	}
+	done(err)
 	return str, err
 }
 
 // GetWithTTL retrieves the value of a key along with its time-to-live (TTL).
 func (store *RedisStore) GetWithTTL(ctx context.Context, key any) (any, time.Duration, error) {
+	ctx, done := store.observe(ctx, "get", key, true)
 	cmd := store.client.B().Get().Key(key.(string)).Cache()
 	res := store.client.DoCache(ctx, cmd, store.opts.ClientSideCacheExpiration)
 	str, err := res.ToString()
 	if rueidis.IsRedisNil(err) {
 		err = NotFoundWithCause(err)
 	}
+	done(err)
 	return str, time.Duration(res.CacheTTL()) * time.Second, err
 }
 
 // Set sets the value of a key.
 func (store *RedisStore) Set(ctx context.Context, key any, value any, options ...Option) error {
+	ctx, done := store.observe(ctx, "set", key, false)
 	opts := newOptions(options...)
 	ttl := int64(opts.Expiration.Seconds())
 
@@ -135,6 +152,7 @@ func (store *RedisStore) Set(ctx context.Context, key any, value any, options ..
 	}
 
 	err := store.client.Do(ctx, cmd).Error()
+	done(err)
 	if err != nil {
 		return err
 	}
@@ -160,7 +178,10 @@ func (store *RedisStore) setTags(ctx context.Context, key any, tags []string) {
 
 // Delete deletes a key.
 func (store *RedisStore) Delete(ctx context.Context, key any) error {
-	return store.client.Do(ctx, store.client.B().Del().Key(key.(string)).Build()).Error()
+	ctx, done := store.observe(ctx, "delete", key, false)
+	err := store.client.Do(ctx, store.client.B().Del().Key(key.(string)).Build()).Error()
+	done(err)
+	return err
 }
 
 // Invalidate invalidates the cache based on the provided options.
@@ -196,3 +217,14 @@ func (store *RedisStore) GetType() string {
 func (store *RedisStore) Clear(ctx context.Context) error {
 	return rueidiscompat.NewAdapter(store.client).FlushAll(ctx).Err()
 }
+
+// Close closes the underlying Redis client.
+func (store *RedisStore) Close() error {
+	store.client.Close()
+	return nil
+}
+
+// HealthCheck pings the Redis server.
+func (store *RedisStore) HealthCheck(ctx context.Context) error {
+	return store.client.Do(ctx, store.client.B().Ping().Build()).Error()
+}
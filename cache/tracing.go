@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/observability"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = observability.InternalTracer("cache")
+
+// observe starts a client span for a cache operation on key and returns the function
+// ending it and recording the metrics of the operation. Lookups are recorded as a hit or a miss.
+// The key is only recorded if the store is configured with TraceKeys.
+func (store *RedisStore) observe(ctx context.Context, operation string, key any, lookup bool) (context.Context, func(err error)) {
+	start := time.Now()
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemRedis,
+		semconv.DBOperationName(operation),
+	}
+	if k, ok := key.(string); ok && store.opts.TraceKeys {
+		attrs = append(attrs, attribute.String("cache.key", k))
+	}
+	ctx, span := tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+	return ctx, func(err error) {
+		store.metrics.record(ctx, operation, lookup, start, err)
+		if lookup {
+			span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+		}
+		if errors.Is(err, &NotFound{}) {
+			err = nil
+		}
+		observability.EndSpan(span, err)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 var cfg Config
 
+var (
+	reloadMu    sync.Mutex
+	reloadHooks []func(cfg *Config)
+)
+
 func init() {
 	err := LoadConfig([]string{"."}, &cfg)
 	if err != nil {
@@ -29,7 +36,13 @@ type Config struct {
 	Messaging     MessagingConfig
 	Logger        LoggerConfig
 	Observability ObservabilityConfig
+	Admin         AdminConfig
+	Health        HealthConfig
+	AccessLog     AccessLogConfig
+	Tenant        TenantConfig
 	Modules       []ModuleConfig
+	// ModuleSigningKeys are the public keys trusted to sign module manifests.
+	ModuleSigningKeys []string
 }
 
 // ServiceConfig represents the service configuration.
@@ -41,7 +54,8 @@ type ServiceConfig struct {
 
 // ServerConfig represents the server configuration.
 type ServerConfig struct {
-	Port int
+	Port            int
+	ShutdownTimeout time.Duration
 }
 
 // ORMConfig represents the ORM configuration.
@@ -59,15 +73,26 @@ type DatabaseConfig struct {
 	SSLMode  string
 	Enable   bool
 	Type     string
+	Tenancy  TenancyConfig
+}
+
+// TenancyConfig represents the tenancy configuration of the database.
+type TenancyConfig struct {
+	Strategy     string
+	Prefix       string
+	MaxOpenConns int
+	IdleTimeout  time.Duration
+	MaxTenants   int
 }
 
 // CacheConfig represents the cache configuration.
 type CacheConfig struct {
-	Addrs    string
-	User     string
-	Password string
-	Type     string
-	Enable   bool
+	Addrs     string
+	User      string
+	Password  string
+	Type      string
+	Enable    bool
+	TraceKeys bool
 }
 
 // OidcConfig represents the OIDC configuration.
@@ -76,6 +101,21 @@ type OidcConfig struct {
 	ClientId     string
 	ClientSecret string
 	Enable       bool
+	Audiences    []string
+	Algorithms   []string
+	JWKSURL      string
+	ClockSkew    time.Duration
+	Issuers      []OidcIssuerConfig
+	RoleClaims   []string
+	ScopeClaims  []string
+}
+
+// OidcIssuerConfig represents a trusted token issuer.
+type OidcIssuerConfig struct {
+	Issuer     string
+	JWKSURL    string
+	Audiences  []string
+	Algorithms []string
 }
 
 // MessagingConfig represents the messaging configuration.
@@ -95,9 +135,23 @@ type ObservabilityConfig struct {
 
 // TracingConfig represents the tracing configuration.
 type TracingConfig struct {
-	Enable   bool
-	Type     string
-	Endpoint string
+	Enable      bool
+	Type        string
+	Endpoint    string
+	Headers     map[string]string
+	TLS         TLSConfig
+	SampleRatio *float64
+	Attributes  map[string]string
+	QueryParams []string
+}
+
+// TLSConfig represents the TLS configuration of a client.
+type TLSConfig struct {
+	Enable             bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
 }
 
 // MetricsConfig represents the metrics configuration.
@@ -105,6 +159,7 @@ type MetricsConfig struct {
 	Enable   bool
 	Type     string
 	Endpoint string
+	Interval time.Duration
 }
 
 // LoggerConfig represents the logger configuration.
@@ -112,12 +167,112 @@ type LoggerConfig struct {
 	Enable   bool
 	Type     string
 	Endpoint string
+	Headers  map[string]string
+	// Level is the minimum level logged, e.g. "debug", "info" or "warn".
+	Level string
+	// Levels overrides the level of named loggers, such as the loggers of modules by module id.
+	Levels   map[string]string
+	Sampling LogSamplingConfig
+	// Redact lists the field keys whose values are replaced before entries are written.
+	Redact []string
+	File   LogFileConfig
+	HTTP   LogHTTPConfig
 }
 
-type ModuleConfig struct {
-	Id     string
-	Name   string
+// LogSamplingConfig represents the sampling of repeated log entries.
+type LogSamplingConfig struct {
+	Initial    int
+	Thereafter int
+}
+
+// LogFileConfig represents the rotation of the file sink.
+type LogFileConfig struct {
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
+}
+
+// LogHTTPConfig represents the batching of the HTTP sink.
+type LogHTTPConfig struct {
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+// AccessLogConfig represents the HTTP access log configuration.
+type AccessLogConfig struct {
+	Enable        bool
+	Fields        []string
+	Headers       []string
+	RedactHeaders []string
+	RedactFields  []string
+	Body          AccessLogBodyConfig
+	SkipPaths     []string
+	ProbePaths    []string
+	ProbeSampling int
+	UserClaim     string
+	TenantClaim   string
+}
+
+// AccessLogBodyConfig represents the capture of request and response bodies in the access log.
+type AccessLogBodyConfig struct {
+	Request  bool
+	Response bool
+	MaxSize  int
+}
+
+// TenantConfig represents the tenant resolution configuration.
+type TenantConfig struct {
+	Resolvers        []string
+	PathParam        string
+	Header           string
+	Claim            string
+	Domain           string
+	MembershipClaims []string
+	Optional         bool
+	AllowAnonymous   bool
+}
+
+// HealthConfig represents the health check configuration.
+type HealthConfig struct {
+	Timeout  time.Duration
+	CacheTTL time.Duration
+}
+
+// AdminConfig represents the admin API configuration.
+type AdminConfig struct {
 	Enable bool
+	Prefix string
+	Role   string
+}
+
+// ModuleConfig represents the configuration of a module.
+type ModuleConfig struct {
+	Id       string
+	Name     string
+	Enable   bool
+	Loader   string
+	Path     string
+	Checksum string
+	Route    ModuleRouteConfig
+	Settings map[string]any
+}
+
+// ModuleRouteConfig represents the routing configuration of a module.
+type ModuleRouteConfig struct {
+	Prefix      string
+	Middlewares []string
+	RateLimit   RateLimitConfig
+	Roles       []string
+	Scopes      []string
+	MaxBodySize int64
+}
+
+// RateLimitConfig represents the rate limit configuration.
+type RateLimitConfig struct {
+	Rate  float64
+	Burst int
 }
 
 // LoadConfig loads the configuration from the specified paths.
@@ -145,6 +300,37 @@ func LoadConfig(configPaths []string, pluginCF interface{}) error {
 	return nil
 }
 
+// OnReload registers a hook called with the new configuration when it is reloaded. Hooks
+// apply the settings that can change at runtime, currently the log levels.
+func OnReload(hook func(cfg *Config)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, hook)
+}
+
+// Reload reads the configuration again and passes it to the hooks registered with OnReload.
+// Only the log levels are applied: the configuration returned by GetConfig is not changed,
+// as components read it when they are created, and other changes need a restart.
+func Reload() error {
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return fmt.Errorf("error reading config file: %v", err)
+		}
+	}
+	var reloaded Config
+	if err := viper.Unmarshal(&reloaded); err != nil {
+		return fmt.Errorf("error unmarshal config: %v", err)
+	}
+
+	reloadMu.Lock()
+	hooks := append([]func(cfg *Config){}, reloadHooks...)
+	reloadMu.Unlock()
+	for _, hook := range hooks {
+		hook(&reloaded)
+	}
+	return nil
+}
+
 // GetConfig returns the application configuration.
 func GetConfig() *Config {
 	return &cfg
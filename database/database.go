@@ -1,12 +1,14 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/trinitytechnology/ebrick/config"
 	"github.com/trinitytechnology/ebrick/database/postgresql"
 	"github.com/trinitytechnology/ebrick/logger"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -28,7 +30,40 @@ func NewDatabase() *gorm.DB {
 			default:
 				logger.Fatal(fmt.Sprintf("Database type %s is not supported", cfg.Type))
 			}
+			if err := registerPlugins(db); err != nil {
+				logger.Fatal("Failed to register database tenant scoping", zap.Error(err))
+			}
 		}
 	})
 	return db
 }
+
+// registerPlugins registers the tenant scoping, and the tracing and metrics plugins when
+// enabled, on a connection. Only the failure of the tenant scoping is returned.
+func registerPlugins(db *gorm.DB) error {
+	if err := db.Use(&TenantPlugin{}); err != nil {
+		return err
+	}
+	if config.GetConfig().Observability.Tracing.Enable {
+		if err := db.Use(&TracingPlugin{}); err != nil {
+			logger.DefaultLogger.Error("Failed to register database tracing", zap.Error(err))
+		}
+	}
+	if config.GetConfig().Observability.Metrics.Enable {
+		if err := db.Use(&MetricsPlugin{}); err != nil {
+			logger.DefaultLogger.Error("Failed to register database metrics", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// HealthCheck pings the database of the given connection.
+func HealthCheck(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"time"
+
+	"github.com/trinitytechnology/ebrick/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+)
+
+const metricsStartKey = "ebrick:metrics_start"
+
+// MetricsPlugin is a GORM plugin recording the duration of the queries by operation and table.
+type MetricsPlugin struct {
+	duration metric.Float64Histogram
+}
+
+// Name implements gorm.Plugin.
+func (p *MetricsPlugin) Name() string {
+	return "ebrick:metrics"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *MetricsPlugin) Initialize(db *gorm.DB) error {
+	duration, err := observability.InternalMeter("database").Float64Histogram("db.client.operation.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of database queries."))
+	if err != nil {
+		return err
+	}
+	p.duration = duration
+
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("ebrick:metrics_before_"+h.operation, p.before); err != nil {
+			return err
+		}
+		if err := h.after("ebrick:metrics_after_"+h.operation, p.after(h.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MetricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (p *MetricsPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		status := "ok"
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			status = "error"
+		}
+		p.duration.Record(db.Statement.Context, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", db.Statement.Table),
+			attribute.String("db.response.status", status),
+		))
+	}
+}
@@ -16,12 +16,9 @@ func InitDB() *gorm.DB {
 	// Get the database configuration from the config package
 	cfg := config.GetConfig()
 	log.Info("Connecting to PostgreSQL database", zap.String("host", cfg.Database.Host), zap.String("dbname", cfg.Database.DBName), zap.String("sslmode", cfg.Database.SSLMode))
-	// Create the connection string using the configuration values
-	dsn := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=%s password=%s",
-		cfg.Database.Host, cfg.Database.User, cfg.Database.DBName, cfg.Database.SSLMode, cfg.Database.Password)
 
 	// Open a connection to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := Open(DSN(cfg.Database))
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
@@ -29,3 +26,14 @@ func InitDB() *gorm.DB {
 	log.Info("Connected to PostgreSQL database")
 	return db
 }
+
+// DSN returns the connection string of the database configuration.
+func DSN(cfg config.DatabaseConfig) string {
+	return fmt.Sprintf("host=%s user=%s dbname=%s sslmode=%s password=%s",
+		cfg.Host, cfg.User, cfg.DBName, cfg.SSLMode, cfg.Password)
+}
+
+// Open opens a connection to the database of the connection string.
+func Open(dsn string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
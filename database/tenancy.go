@@ -0,0 +1,379 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/database/postgresql"
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Tenancy strategies, see config.TenancyConfig.
+const (
+	TenancyShared   = "shared"
+	TenancySchema   = "schema"
+	TenancyDatabase = "database"
+
+	defaultTenantPrefix       = "tenant_"
+	defaultTenantMaxOpenConns = 5
+	defaultTenantIdleTimeout  = 5 * time.Minute
+	defaultMaxTenants         = 100
+
+	// Tenants whose connection failed are retried after a delay doubling from
+	// tenantRetryDelay up to maxTenantRetryDelay.
+	tenantRetryDelay    = time.Second
+	maxTenantRetryDelay = time.Minute
+)
+
+var (
+	// ErrDataSourcesClosed is returned when a tenant connection is requested after Close.
+	ErrDataSourcesClosed = errors.New("tenant data sources are closed")
+	// ErrTenantUnavailable is returned, wrapping the last error, while the connection of a
+	// tenant that failed is not retried yet.
+	ErrTenantUnavailable = errors.New("tenant database is unavailable")
+)
+
+// tenantPrefixPattern restricts prefixes to identifiers that need no quoting.
+var tenantPrefixPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// DefaultTenantDataSources resolves the tenant connections of DefaultDataSource. It is nil
+// when the database is disabled.
+var DefaultTenantDataSources = newDefaultTenantDataSources()
+
+func newDefaultTenantDataSources() *TenantDataSources {
+	if DefaultDataSource == nil {
+		return nil
+	}
+	sources, err := NewTenantDataSources(DefaultDataSource, config.GetConfig().Database)
+	if err != nil {
+		logger.DefaultLogger.Fatal("Invalid tenancy configuration", zap.Error(err))
+	}
+	return sources
+}
+
+// TenantDataSources resolves the connection of the tenant of a context according to the tenancy
+// strategy of the configuration. With the shared strategy all tenants use the default connection,
+// scoped by TenantPlugin. With the schema and database strategies each tenant gets its own pooled
+// connection, opened on first use. The pools of the least recently used tenants are evicted beyond
+// the configured maximum number of tenants, and closed once no request uses them, see Acquire.
+type TenantDataSources struct {
+	db          *gorm.DB
+	cfg         config.DatabaseConfig
+	strategy    string
+	prefix      string
+	maxOpen     int
+	idleTimeout time.Duration
+	maxTenants  int
+	now         func() time.Time
+
+	mu sync.Mutex
+	// sources holds the elements of lru, whose values are *tenantSource, most recently used first.
+	sources  map[uuid.UUID]*list.Element
+	lru      *list.List
+	failures map[uuid.UUID]*tenantFailure
+	closed   bool
+}
+
+// tenantSource is the connection of a tenant, opened once.
+type tenantSource struct {
+	id   uuid.UUID
+	once sync.Once
+	db   *gorm.DB
+	err  error
+	// refs counts the acquisitions not released yet; an evicted source is closed when it drops
+	// to zero. Both are guarded by TenantDataSources.mu.
+	refs    int
+	evicted bool
+}
+
+// tenantFailure records the failed connections of a tenant.
+type tenantFailure struct {
+	count   int
+	retryAt time.Time
+	err     error
+}
+
+// NewTenantDataSources returns the tenant data sources of the default connection db, opened
+// with cfg.
+func NewTenantDataSources(db *gorm.DB, cfg config.DatabaseConfig) (*TenantDataSources, error) {
+	tenancy := cfg.Tenancy
+	s := &TenantDataSources{
+		db:          db,
+		cfg:         cfg,
+		strategy:    tenancy.Strategy,
+		prefix:      tenancy.Prefix,
+		maxOpen:     tenancy.MaxOpenConns,
+		idleTimeout: tenancy.IdleTimeout,
+		maxTenants:  tenancy.MaxTenants,
+		now:         time.Now,
+		sources:     make(map[uuid.UUID]*list.Element),
+		lru:         list.New(),
+		failures:    make(map[uuid.UUID]*tenantFailure),
+	}
+	if s.strategy == "" {
+		s.strategy = TenancyShared
+	}
+	if s.prefix == "" {
+		s.prefix = defaultTenantPrefix
+	}
+	if s.maxOpen <= 0 {
+		s.maxOpen = defaultTenantMaxOpenConns
+	}
+	if s.idleTimeout <= 0 {
+		s.idleTimeout = defaultTenantIdleTimeout
+	}
+	if s.maxTenants <= 0 {
+		s.maxTenants = defaultMaxTenants
+	}
+
+	switch s.strategy {
+	case TenancyShared:
+	case TenancySchema, TenancyDatabase:
+		if cfg.Type != "postgresql" {
+			return nil, fmt.Errorf("tenancy strategy %s is not supported by database type %s", s.strategy, cfg.Type)
+		}
+		if !tenantPrefixPattern.MatchString(s.prefix) {
+			return nil, fmt.Errorf("invalid tenancy prefix: %s", s.prefix)
+		}
+	default:
+		return nil, fmt.Errorf("unknown tenancy strategy: %s", s.strategy)
+	}
+	return s, nil
+}
+
+// Strategy returns the tenancy strategy.
+func (s *TenantDataSources) Strategy() string {
+	return s.strategy
+}
+
+// Default returns the default connection.
+func (s *TenantDataSources) Default() *gorm.DB {
+	return s.db
+}
+
+// DB returns the connection of the tenant of ctx, bound to ctx. With the schema and database
+// strategies it fails with tenant.ErrTenantRequired if ctx carries no tenant, and with
+// ErrTenantUnavailable while a tenant whose connection failed is not retried yet. The connection
+// is released when ctx is done, so the pool of an evicted tenant stays open while ctx is in use;
+// use Acquire with contexts that are never done.
+func (s *TenantDataSources) DB(ctx context.Context) (*gorm.DB, error) {
+	db, release, err := s.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	context.AfterFunc(ctx, release)
+	return db, nil
+}
+
+// Acquire returns the connection of the tenant of ctx like DB, and a function releasing it once
+// it is no longer used. The pool of an evicted tenant is closed when its last acquisition is released.
+func (s *TenantDataSources) Acquire(ctx context.Context) (*gorm.DB, func(), error) {
+	if s.strategy == TenancyShared {
+		return s.db.WithContext(ctx), func() {}, nil
+	}
+	id, err := tenant.IDFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, nil, ErrDataSourcesClosed
+	}
+	if f, ok := s.failures[id]; ok && s.now().Before(f.retryAt) {
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("%w: %w", ErrTenantUnavailable, f.err)
+	}
+	var evicted []*tenantSource
+	elem, ok := s.sources[id]
+	if ok {
+		s.lru.MoveToFront(elem)
+		elem.Value.(*tenantSource).refs++
+	} else {
+		elem = s.lru.PushFront(&tenantSource{id: id, refs: 1})
+		s.sources[id] = elem
+		evicted = s.evict()
+	}
+	s.mu.Unlock()
+
+	for _, source := range evicted {
+		s.closeEvicted(source)
+	}
+
+	// Connections are opened outside the lock so a slow tenant does not block the others.
+	source := elem.Value.(*tenantSource)
+	source.once.Do(func() {
+		source.db, source.err = s.open(id)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if source.err == nil {
+			delete(s.failures, id)
+			return
+		}
+		// Failed connections are retried by a later request of the tenant, after a delay.
+		if s.sources[id] == elem {
+			s.lru.Remove(elem)
+			delete(s.sources, id)
+		}
+		s.recordFailure(id, source.err)
+	})
+	var once sync.Once
+	release := func() {
+		once.Do(func() { s.release(source) })
+	}
+	if source.err != nil {
+		release()
+		return nil, nil, source.err
+	}
+	return source.db.WithContext(ctx), release, nil
+}
+
+// release releases an acquisition of a source, closing the source if it was evicted.
+func (s *TenantDataSources) release(source *tenantSource) {
+	s.mu.Lock()
+	source.refs--
+	closing := source.refs == 0 && source.evicted
+	s.mu.Unlock()
+	if closing {
+		s.closeEvicted(source)
+	}
+}
+
+// closeEvicted closes the source of an evicted tenant.
+func (s *TenantDataSources) closeEvicted(source *tenantSource) {
+	if err := closeSource(source); err != nil {
+		logger.DefaultLogger.Error("Failed to close tenant database", zap.String("tenant", source.id.String()), zap.Error(err))
+	}
+}
+
+// evict removes the least recently used tenants beyond the maximum and returns the sources that
+// can be closed, as no acquisition of them is left. The others are closed when released. It must
+// be called with s.mu held.
+func (s *TenantDataSources) evict() []*tenantSource {
+	var evicted []*tenantSource
+	for s.lru.Len() > s.maxTenants {
+		source := s.lru.Remove(s.lru.Back()).(*tenantSource)
+		delete(s.sources, source.id)
+		source.evicted = true
+		if source.refs == 0 {
+			evicted = append(evicted, source)
+		}
+	}
+	return evicted
+}
+
+// recordFailure delays the next connection of a tenant after a failure. It must be called with
+// s.mu held.
+func (s *TenantDataSources) recordFailure(id uuid.UUID, err error) {
+	now := s.now()
+	if len(s.failures) >= s.maxTenants {
+		// Forget the tenants that can be retried, so that failures do not accumulate.
+		for fid, f := range s.failures {
+			if !now.Before(f.retryAt) {
+				delete(s.failures, fid)
+			}
+		}
+	}
+	f, ok := s.failures[id]
+	if !ok {
+		f = &tenantFailure{}
+		s.failures[id] = f
+	}
+	delay := maxTenantRetryDelay
+	if f.count < 6 {
+		delay = min(tenantRetryDelay<<f.count, maxTenantRetryDelay)
+	}
+	f.count++
+	f.retryAt = now.Add(delay)
+	f.err = err
+}
+
+// Close closes the connections of the tenants, including those still acquired. The default
+// connection is left open.
+func (s *TenantDataSources) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var sources []*tenantSource
+	for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+		sources = append(sources, elem.Value.(*tenantSource))
+	}
+	s.sources = make(map[uuid.UUID]*list.Element)
+	s.lru.Init()
+	s.mu.Unlock()
+
+	var errs []error
+	for _, source := range sources {
+		if err := closeSource(source); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeSource closes the connection of a tenant, waiting for it to be opened.
+func closeSource(source *tenantSource) error {
+	source.once.Do(func() {
+		source.err = ErrDataSourcesClosed
+	})
+	if source.db == nil {
+		return nil
+	}
+	sqlDB, err := source.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Name returns the name of the schema or database of a tenant.
+func (s *TenantDataSources) Name(id uuid.UUID) string {
+	return s.prefix + strings.ReplaceAll(id.String(), "-", "")
+}
+
+// open opens the connection of a tenant.
+func (s *TenantDataSources) open(id uuid.UUID) (*gorm.DB, error) {
+	name := s.Name(id)
+	cfg := s.cfg
+	var dsn string
+	switch s.strategy {
+	case TenancySchema:
+		// search_path is set on every connection of the pool when it is established. It comes
+		// first as the DSN ends with the password, which may be empty.
+		dsn = "search_path=" + name + " " + postgresql.DSN(cfg)
+	case TenancyDatabase:
+		cfg.DBName = name
+		dsn = postgresql.DSN(cfg)
+	}
+
+	db, err := postgresql.Open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s of tenant %s: %w", s.strategy, id, err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := registerPlugins(db); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(s.maxOpen)
+	sqlDB.SetMaxIdleConns(s.maxOpen)
+	sqlDB.SetConnMaxIdleTime(s.idleTimeout)
+
+	logger.DefaultLogger.Info("Connected to tenant database", zap.String("tenant", id.String()),
+		zap.String("strategy", s.strategy), zap.String(s.strategy, name))
+	return db, nil
+}
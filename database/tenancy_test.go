@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/tenant"
+)
+
+func newTestDataSources(t *testing.T, maxTenants int) *TenantDataSources {
+	t.Helper()
+	// The socket directory does not exist, so connections fail without a server.
+	s, err := NewTenantDataSources(nil, config.DatabaseConfig{
+		Type:    "postgresql",
+		Host:    "/nonexistent",
+		User:    "test",
+		SSLMode: "disable",
+		Tenancy: config.TenancyConfig{Strategy: TenancySchema, MaxTenants: maxTenants},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTenantDataSourcesBackOff(t *testing.T) {
+	s := newTestDataSources(t, 10)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	ctx := tenant.WithTenant(context.Background(), &tenant.Tenant{ID: uuid.New()})
+
+	if _, err := s.DB(ctx); err == nil || errors.Is(err, ErrTenantUnavailable) {
+		t.Fatalf("got %v, want a connection error", err)
+	}
+	if _, err := s.DB(ctx); !errors.Is(err, ErrTenantUnavailable) {
+		t.Fatalf("got %v, want %v", err, ErrTenantUnavailable)
+	}
+
+	// The connection is retried after the delay, which doubles with each failure.
+	now = now.Add(tenantRetryDelay)
+	if _, err := s.DB(ctx); err == nil || errors.Is(err, ErrTenantUnavailable) {
+		t.Fatalf("got %v, want a connection error", err)
+	}
+	now = now.Add(tenantRetryDelay)
+	if _, err := s.DB(ctx); !errors.Is(err, ErrTenantUnavailable) {
+		t.Fatalf("got %v, want %v", err, ErrTenantUnavailable)
+	}
+	if s.lru.Len() != 0 || len(s.sources) != 0 {
+		t.Errorf("failed tenants are kept: %d", s.lru.Len())
+	}
+}
+
+func TestTenantDataSourcesEvictsLeastRecentlyUsed(t *testing.T) {
+	s := newTestDataSources(t, 2)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	add := func(id uuid.UUID, refs int) []*tenantSource {
+		s.sources[id] = s.lru.PushFront(&tenantSource{id: id, refs: refs})
+		return s.evict()
+	}
+
+	add(ids[0], 0)
+	add(ids[1], 0)
+	s.lru.MoveToFront(s.sources[ids[0]])
+	evicted := add(ids[2], 0)
+	if len(evicted) != 1 || evicted[0].id != ids[1] {
+		t.Fatalf("got %v evicted, want %s", evicted, ids[1])
+	}
+	if _, ok := s.sources[ids[1]]; ok || s.lru.Len() != 2 {
+		t.Errorf("evicted tenant is kept")
+	}
+	if err := closeSource(evicted[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(evicted[0].err, ErrDataSourcesClosed) {
+		t.Errorf("got %v, want %v", evicted[0].err, ErrDataSourcesClosed)
+	}
+
+	// A source still acquired by a request is closed once released.
+	held := s.sources[ids[0]].Value.(*tenantSource)
+	held.refs = 1
+	if evicted := add(ids[3], 0); len(evicted) != 0 {
+		t.Fatalf("got %v evicted, want none to close", evicted)
+	}
+	if _, ok := s.sources[ids[0]]; ok || !held.evicted {
+		t.Errorf("held tenant is not evicted")
+	}
+	if held.err != nil {
+		t.Fatalf("held source is closed: %v", held.err)
+	}
+	s.release(held)
+	if !errors.Is(held.err, ErrDataSourcesClosed) {
+		t.Errorf("got %v, want %v once released", held.err, ErrDataSourcesClosed)
+	}
+}
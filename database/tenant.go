@@ -0,0 +1,215 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	tenantRequiredKey = "ebrick:tenant_required"
+	tenantFieldName   = "TenantId"
+)
+
+// ErrCrossTenant is returned when a statement writes a row of another tenant than the one
+// of its context.
+var ErrCrossTenant = errors.New("row belongs to another tenant")
+
+// TenantPlugin is a GORM plugin scoping the statements on models with a TenantId field, such as
+// those embedding entity.TenantAuditEntity, to the tenant of the statement context, see
+// tenant.WithTenant. Queries, updates and deletes are filtered by the tenant, created rows get
+// its ID, and writing rows of another tenant fails with ErrCrossTenant. Statements without a
+// tenant in their context are not scoped unless the session requires one, see RequireTenant.
+// Raw SQL is never scoped.
+type TenantPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (p *TenantPlugin) Name() string {
+	return "ebrick:tenant"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		register  func(name string, fn func(*gorm.DB)) error
+		fn        func(*gorm.DB)
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, p.beforeCreate},
+		{"query", cb.Query().Before("gorm:query").Register, p.beforeQuery},
+		{"update", cb.Update().Before("gorm:update").Register, p.beforeUpdate},
+		{"delete", cb.Delete().Before("gorm:delete").Register, p.beforeDelete},
+		{"row", cb.Row().Before("gorm:row").Register, p.beforeQuery},
+	}
+	for _, h := range hooks {
+		if err := h.register("ebrick:tenant_"+h.operation, h.fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequireTenant returns a session whose statements on tenant models fail with
+// tenant.ErrTenantRequired if their context carries no tenant.
+func RequireTenant(db *gorm.DB) *gorm.DB {
+	return db.Set(tenantRequiredKey, true).Session(&gorm.Session{})
+}
+
+func (p *TenantPlugin) beforeCreate(db *gorm.DB) {
+	field, id, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+	setTenant(db, field, id, db.Statement.ReflectValue)
+
+	// Upserts, as done by Save for rows that were not updated, must not overwrite rows of other tenants.
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, tenantCondition(field, id))
+			c.Expression = onConflict
+			db.Statement.Clauses["ON CONFLICT"] = c
+		}
+	}
+}
+
+func (p *TenantPlugin) beforeQuery(db *gorm.DB) {
+	field, id, ok := statementTenant(db)
+	if !ok {
+		return
+	}
+	addTenantCondition(db, field, id)
+}
+
+func (p *TenantPlugin) beforeUpdate(db *gorm.DB) {
+	field, id, ok := statementTenant(db)
+	if !ok || !hasConditions(db) {
+		return
+	}
+	switch dest := db.Statement.Dest.(type) {
+	case map[string]any:
+		for _, key := range []string{field.DBName, field.Name} {
+			if v, ok := dest[key]; ok && fmt.Sprint(v) != id.String() {
+				db.AddError(fmt.Errorf("%w: %v", ErrCrossTenant, v))
+			}
+		}
+	default:
+		// Save writes all the fields of the model, including its tenant.
+		setTenant(db, field, id, reflect.Indirect(reflect.ValueOf(dest)))
+	}
+	addTenantCondition(db, field, id)
+}
+
+func (p *TenantPlugin) beforeDelete(db *gorm.DB) {
+	field, id, ok := statementTenant(db)
+	if !ok || !hasConditions(db) {
+		return
+	}
+	addTenantCondition(db, field, id)
+}
+
+// statementTenant returns the tenant field of the statement model and the tenant of the
+// statement context, and whether the statement is scoped to a tenant.
+func statementTenant(db *gorm.DB) (*schema.Field, uuid.UUID, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, uuid.Nil, false
+	}
+	field := db.Statement.Schema.LookUpField(tenantFieldName)
+	if field == nil {
+		return nil, uuid.Nil, false
+	}
+	id, err := tenant.IDFromContext(db.Statement.Context)
+	if err != nil {
+		if required, _ := db.Get(tenantRequiredKey); required == true {
+			db.AddError(err)
+		}
+		return nil, uuid.Nil, false
+	}
+	return field, id, true
+}
+
+// addTenantCondition adds the tenant condition to the WHERE clause of the statement. The existing
+// conditions are grouped first, as GORM would otherwise join the tenant condition to a trailing
+// Or condition, matching the rows of all the tenants.
+func addTenantCondition(db *gorm.DB, field *schema.Field, id uuid.UUID) {
+	cond := tenantCondition(field, id)
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			where.Exprs = []clause.Expression{groupedConditions{clause.And(where.Exprs...)}, cond}
+			c.Expression = where
+			db.Statement.Clauses["WHERE"] = c
+			return
+		}
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{cond}})
+}
+
+// groupedConditions builds conditions in parentheses, whatever their operators.
+type groupedConditions struct {
+	clause.Expression
+}
+
+// Build implements clause.Expression.
+func (g groupedConditions) Build(builder clause.Builder) {
+	builder.WriteByte('(')
+	clause.Where{Exprs: []clause.Expression{g.Expression}}.Build(builder)
+	builder.WriteByte(')')
+}
+
+// tenantCondition returns the condition matching the rows of the tenant.
+func tenantCondition(field *schema.Field, id uuid.UUID) clause.Expression {
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: id}
+}
+
+// setTenant sets the tenant of the rows written from v, a struct or a slice of structs,
+// and fails with ErrCrossTenant for rows of another tenant.
+func setTenant(db *gorm.DB, field *schema.Field, id uuid.UUID, v reflect.Value) {
+	ctx := db.Statement.Context
+	set := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct || row.Type() != db.Statement.Schema.ModelType {
+			return
+		}
+		current, zero := field.ValueOf(ctx, row)
+		if zero {
+			db.AddError(field.Set(ctx, row, id))
+			return
+		}
+		if fmt.Sprint(current) != id.String() {
+			db.AddError(fmt.Errorf("%w: %v", ErrCrossTenant, current))
+		}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			set(v.Index(i))
+		}
+	default:
+		set(v)
+	}
+}
+
+// hasConditions reports whether an update or delete has conditions, so adding the tenant
+// condition does not turn a statement GORM rejects with ErrMissingWhereClause into one
+// writing all the rows of the tenant.
+func hasConditions(db *gorm.DB) bool {
+	if _, ok := db.Statement.Clauses["WHERE"]; ok || db.AllowGlobalUpdate {
+		return true
+	}
+	v := db.Statement.ReflectValue
+	if v.Kind() != reflect.Struct {
+		return v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+	}
+	for _, pf := range db.Statement.Schema.PrimaryFields {
+		if _, zero := pf.ValueOf(db.Statement.Context, v); !zero {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type tenantItem struct {
+	ID        uuid.UUID
+	TenantId  uuid.UUID
+	Name      string
+	Code      string
+	DeletedAt gorm.DeletedAt
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost user=test dbname=test sslmode=disable"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(&TenantPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTenantPluginGroupsOrConditions(t *testing.T) {
+	db := newDryRunDB(t)
+	id := uuid.MustParse("aaaaaaaa-0000-0000-0000-000000000000")
+	ctx := tenant.WithTenant(context.Background(), &tenant.Tenant{ID: id})
+	tx := RequireTenant(db).WithContext(ctx)
+
+	tests := []struct {
+		name string
+		run  func(tx *gorm.DB) *gorm.DB
+		want string
+	}{
+		{
+			name: "or",
+			run: func(tx *gorm.DB) *gorm.DB {
+				return tx.Or("name = ?", "a").Or("code = ?", "b").Find(&[]tenantItem{})
+			},
+			want: `SELECT * FROM "tenant_items" WHERE (name = $1 OR code = $2) AND "tenant_items"."tenant_id" = $3 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+		{
+			name: "where or",
+			run: func(tx *gorm.DB) *gorm.DB {
+				return tx.Where("name = ?", "a").Or("code = ?", "b").Find(&[]tenantItem{})
+			},
+			want: `SELECT * FROM "tenant_items" WHERE (name = $1 OR code = $2) AND "tenant_items"."tenant_id" = $3 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+		{
+			name: "raw or",
+			run: func(tx *gorm.DB) *gorm.DB {
+				return tx.Or("name = ? OR code = ?", "a", "b").Find(&[]tenantItem{})
+			},
+			want: `SELECT * FROM "tenant_items" WHERE (name = $1 OR code = $2) AND "tenant_items"."tenant_id" = $3 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+		{
+			name: "count",
+			run: func(tx *gorm.DB) *gorm.DB {
+				var count int64
+				return tx.Model(&tenantItem{}).Where("name = ?", "a").Or("code = ?", "b").Count(&count)
+			},
+			want: `SELECT count(*) FROM "tenant_items" WHERE (name = $1 OR code = $2) AND "tenant_items"."tenant_id" = $3 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+		{
+			name: "update",
+			run: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&tenantItem{}).Where("name = ?", "a").Or("code = ?", "b").Update("name", "c")
+			},
+			want: `UPDATE "tenant_items" SET "name"=$1 WHERE (name = $2 OR code = $3) AND "tenant_items"."tenant_id" = $4 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+		{
+			name: "delete",
+			run: func(tx *gorm.DB) *gorm.DB {
+				return tx.Where("name = ?", "a").Or("code = ?", "b").Delete(&tenantItem{})
+			},
+			want: `UPDATE "tenant_items" SET "deleted_at"=$1 WHERE (name = $2 OR code = $3) AND "tenant_items"."tenant_id" = $4 AND "tenant_items"."deleted_at" IS NULL`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.run(tx)
+			if result.Error != nil {
+				t.Fatal(result.Error)
+			}
+			if got := result.Statement.SQL.String(); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantPluginRequiresTenant(t *testing.T) {
+	db := newDryRunDB(t)
+	err := RequireTenant(db).WithContext(context.Background()).Find(&[]tenantItem{}).Error
+	if err != tenant.ErrTenantRequired {
+		t.Errorf("got %v, want %v", err, tenant.ErrTenantRequired)
+	}
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/trinitytechnology/ebrick/observability"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "ebrick:tracing_span"
+
+// TracingPlugin is a GORM plugin creating a client span for each query, as a child of the
+// span in the statement context. Pass the request context with db.WithContext to link them.
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+// Name implements gorm.Plugin.
+func (p *TracingPlugin) Name() string {
+	return "ebrick:tracing"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	p.tracer = observability.InternalTracer("database")
+
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("ebrick:tracing_before_"+h.operation, p.before(h.operation)); err != nil {
+			return err
+		}
+		if err := h.after("ebrick:tracing_after_"+h.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *TracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		ctx, span := p.tracer.Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(dbSystem(db)),
+				semconv.DBOperationName(operation),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func (p *TracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	// The statement uses placeholders, so parameter values are not recorded.
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	observability.EndSpan(span, err)
+}
+
+// dbSystem returns the semantic convention name of the database of the connection.
+func dbSystem(db *gorm.DB) string {
+	name := db.Dialector.Name()
+	if name == "postgres" {
+		return semconv.DBSystemPostgreSQL.Value.AsString()
+	}
+	return name
+}
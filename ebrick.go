@@ -2,9 +2,21 @@ package ebrick
 
 import (
 	"context"
-	"time"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/database"
+	"github.com/trinitytechnology/ebrick/health"
+	"github.com/trinitytechnology/ebrick/logger"
 	"github.com/trinitytechnology/ebrick/module"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/utils"
+	"github.com/trinitytechnology/ebrick/web/middleware"
 	"go.uber.org/zap"
 )
 
@@ -14,11 +26,14 @@ type App interface {
 	Options() *Options
 	RegisterModules(m ...module.Module) error
 	Start() error
+	Stop(ctx context.Context) error
 }
 
 type application struct {
-	opts *Options
-	mm   *module.ModuleManager
+	opts     *Options
+	mm       *module.ModuleManager
+	stopOnce sync.Once
+	stopErr  error
 }
 
 // Version implements App.
@@ -39,38 +54,187 @@ func (a *application) Options() *Options {
 func NewApplication(opts ...Option) App {
 	op := newOptions(opts...)
 
+	if err := observability.DefaultProvidersErr(); err != nil {
+		op.Logger.Fatal("Invalid observability configuration", zap.Error(err))
+	}
+
+	router := op.HttpServer.GetRouter()
 	mm := module.NewModuleManager(
 		module.Logger(op.Logger),
 		module.Database(op.Database),
+		module.TenantDataSources(op.TenantDataSources),
 		module.Cache(op.Cache),
 		module.EventStream(op.EventStream),
-		module.Router(op.HttpServer.GetRouter()),
+		module.Router(router),
+		module.Health(op.Health),
 	)
 
+	registerHealthChecks(op)
+
+	if adminCfg := config.GetConfig().Admin; adminCfg.Enable {
+		adminRouter := newAdminRouter(router, op.Logger, &adminCfg)
+		mm.RegisterAdminRoutes(adminRouter)
+		logger.RegisterAdminRoutes(adminRouter)
+	}
+
 	return &application{
 		opts: op,
 		mm:   mm,
 	}
 }
 
+// registerHealthChecks registers the checks of the infrastructure used by the application.
+func registerHealthChecks(op *Options) {
+	if op.Database != nil {
+		op.Health.Register("database", database.HealthCheck(op.Database))
+	}
+	if hc, ok := op.Cache.(health.Checker); ok {
+		op.Health.Register("cache", hc.HealthCheck)
+	}
+	if hc, ok := op.EventStream.(health.Checker); ok {
+		op.Health.Register("messaging", hc.HealthCheck)
+	}
+}
+
+// newAdminRouter creates the admin route group, protected by OIDC authentication and the configured role.
+// The application stops if either is missing, as the admin API would otherwise be open to anyone.
+func newAdminRouter(router *gin.Engine, log *zap.Logger, cfg *config.AdminConfig) *gin.RouterGroup {
+	prefix := utils.Default(&cfg.Prefix, "/admin")
+	if !config.GetConfig().Oidc.Enable || utils.IsBlank(&cfg.Role) {
+		log.Fatal("Admin API requires OIDC authentication and a role", zap.String("prefix", prefix),
+			zap.Bool("oidc", config.GetConfig().Oidc.Enable), zap.String("role", cfg.Role))
+	}
+	return router.Group(prefix, middleware.OIDCAuthMiddleware(), middleware.RequireRole(cfg.Role))
+}
+
 // Start implements App.
+// It initializes modules in dependency order and starts them, then blocks
+// until the HTTP server fails or a SIGINT/SIGTERM is received, and gracefully
+// stops the application within Options.ShutdownTimeout. A SIGHUP reloads the
+// configuration.
 func (a *application) Start() error {
 	log := a.opts.Logger
-	defer func() {
-		// Increase timeout for tracer provider shutdown
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	a.mm.LoadDynamicModules()
+
+	err := a.mm.InitializeModules()
+	if err == nil {
+		err = a.mm.StartModules(context.Background())
+	}
+	if err != nil {
+		log.Error("failed to start modules", zap.Error(err))
+		ctx, cancel := context.WithTimeout(context.Background(), a.opts.ShutdownTimeout)
 		defer cancel()
+		return errors.Join(err, a.Stop(ctx))
+	}
+	a.mm.FreezeRoutes()
+	a.opts.Health.SetStarted()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- a.opts.HttpServer.Start()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	// SIGHUP reloads the log levels from the configuration, see config.Reload.
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+wait:
+	for {
+		select {
+		case err = <-serverErr:
+			if err != nil {
+				log.Error("HTTP server stopped unexpectedly", zap.Error(err))
+			}
+			break wait
+		case sig := <-quit:
+			log.Info("Received shutdown signal", zap.String("signal", sig.String()))
+			break wait
+		case <-reload:
+			log.Info("Reloading log levels")
+			if err := config.Reload(); err != nil {
+				log.Error("failed to reload configuration", zap.Error(err))
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.opts.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(err, a.Stop(ctx))
+}
+
+// Stop implements App.
+// It drains the HTTP server, stops started modules in reverse initialization order,
+// closes the tenant databases, the event stream and cache and flushes the tracer provider.
+// Calling Stop more than once returns the result of the first call.
+func (a *application) Stop(ctx context.Context) error {
+	a.stopOnce.Do(func() {
+		a.stopErr = a.stop(ctx)
+	})
+	return a.stopErr
+}
+
+func (a *application) stop(ctx context.Context) error {
+	log := a.opts.Logger
+	log.Info("Stopping application")
+	a.opts.Health.SetStopping()
+
+	var errs []error
+	if err := a.opts.HttpServer.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := a.mm.StopModules(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if a.opts.TenantDataSources != nil {
+		if err := a.opts.TenantDataSources.Close(); err != nil {
+			log.Error("failed to close tenant databases", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if a.opts.EventStream != nil {
+		if err := a.opts.EventStream.Close(); err != nil {
+			log.Error("failed to close event stream", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if a.opts.Cache != nil {
+		if err := a.opts.Cache.Close(); err != nil {
+			log.Error("failed to close cache", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+
+	if a.opts.TracerProvider != nil {
 		if err := a.opts.TracerProvider.Shutdown(ctx); err != nil {
-			log.Fatal("failed to shutdown tracer provider", zap.Error(err))
+			log.Error("failed to shutdown tracer provider", zap.Error(err))
+			errs = append(errs, err)
 		}
-	}()
-	a.mm.LoadDynamicModules()
-	err := a.opts.HttpServer.Start()
+	}
+
+	if a.opts.MeterProvider != nil {
+		if err := a.opts.MeterProvider.Shutdown(ctx); err != nil {
+			log.Error("failed to shutdown meter provider", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
 
-	return err
+	log.Info("Application stopped")
+	if err := logger.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
-// RegisterModule registers a module.
+// RegisterModule registers modules. They are initialized when the application starts.
 func (a *application) RegisterModules(m ...module.Module) error {
 	for _, module := range m {
 		err := a.mm.RegisterModule(module)
@@ -8,3 +8,13 @@ type TenantAuditEntity struct {
 	AuditEntity
 	TenantId uuid.UUID `gorm:"type:uuid;index; not null" json:"tenant_id" validate:"required"`
 }
+
+// GetTenantId returns the tenant of the entity.
+func (te *TenantAuditEntity) GetTenantId() uuid.UUID {
+	return te.TenantId
+}
+
+// SetTenantId sets the tenant of the entity.
+func (te *TenantAuditEntity) SetTenantId(id uuid.UUID) {
+	te.TenantId = id
+}
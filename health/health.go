@@ -0,0 +1,247 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+var DefaultRegistry = NewRegistry()
+
+// CheckFunc checks a dependency and returns an error if it is unhealthy. It must return when ctx
+// is done: a check still running after its timeout is reported down and is not run again until
+// it returns.
+type CheckFunc func(ctx context.Context) error
+
+// Checker is implemented by components that can check their own health,
+// such as the cache and the event stream.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of all checks. Status is DOWN if a critical check failed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	fn       CheckFunc
+	timeout  time.Duration
+	critical bool
+
+	mu      sync.Mutex
+	result  Result
+	checked time.Time
+	// running is the call in flight, shared by concurrent probes.
+	running *call
+}
+
+// call is a run of a check.
+type call struct {
+	ctx    context.Context
+	done   chan struct{}
+	result Result
+}
+
+// Registry holds the health checks of the application.
+// Results are cached for the configured TTL so frequent probes do not overload dependencies.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   map[string]*check
+	timeout  time.Duration
+	cacheTTL time.Duration
+	started  atomic.Bool
+	stopping atomic.Bool
+}
+
+// CheckOption configures a check.
+type CheckOption func(*check)
+
+// Critical sets whether a failure of the check makes the application not ready. Checks are critical by default.
+func Critical(critical bool) CheckOption {
+	return func(c *check) {
+		c.critical = critical
+	}
+}
+
+// Timeout sets the timeout of the check.
+func Timeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// NewRegistry creates a registry using the timeout and cache TTL of the health configuration.
+func NewRegistry() *Registry {
+	cfg := config.GetConfig().Health
+	r := &Registry{
+		checks:   make(map[string]*check),
+		timeout:  cfg.Timeout,
+		cacheTTL: cfg.CacheTTL,
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	if r.cacheTTL <= 0 {
+		r.cacheTTL = defaultCacheTTL
+	}
+	return r
+}
+
+// Register registers a check under the given name, replacing any check with the same name.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	c := &check{fn: fn, timeout: r.timeout, critical: true}
+	for _, o := range opts {
+		o(c)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// Unregister removes the check registered under the given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the sorted names of the registered checks.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetStarted marks the application as started, after all modules are loaded and started.
+func (r *Registry) SetStarted() {
+	r.started.Store(true)
+}
+
+// Started reports whether the application has started.
+func (r *Registry) Started() bool {
+	return r.started.Load()
+}
+
+// SetStopping marks the application as stopping so it stops receiving traffic.
+func (r *Registry) SetStopping() {
+	r.stopping.Store(true)
+}
+
+// Ready reports whether the application has started, is not stopping and all critical checks pass.
+func (r *Registry) Ready(ctx context.Context) (bool, Report) {
+	report := r.Run(ctx)
+	return r.Started() && !r.stopping.Load() && report.Status == StatusUp, report
+}
+
+// Run runs all checks concurrently and returns the report.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]*check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func(name string, c *check) {
+			defer wg.Done()
+			res := c.run(ctx, r.cacheTTL)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Critical && res.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(name, c)
+	}
+	wg.Wait()
+	return report
+}
+
+// run runs the check, or returns the cached result if it is still fresh. Concurrent probes share
+// the call in flight, and no call is started while a previous one has not returned.
+func (c *check) run(ctx context.Context, ttl time.Duration) Result {
+	c.mu.Lock()
+	if !c.checked.IsZero() && time.Since(c.checked) < ttl {
+		defer c.mu.Unlock()
+		return c.result
+	}
+	cl := c.running
+	if cl == nil {
+		cl = c.start(ctx)
+		c.running = cl
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.result
+	case <-cl.ctx.Done():
+		// The check ignores its context: it is reported down until it returns.
+		res := Result{Status: StatusDown, Critical: c.critical, Error: cl.ctx.Err().Error(), Duration: c.timeout.String()}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.running == cl {
+			c.result, c.checked = res, time.Now()
+		}
+		return res
+	case <-ctx.Done():
+		return Result{Status: StatusDown, Critical: c.critical, Error: ctx.Err().Error()}
+	}
+}
+
+// start runs the check in the background, with its timeout. It must be called with c.mu held.
+func (c *check) start(ctx context.Context) *call {
+	// The call outlives the probe starting it when other probes share it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	cl := &call{ctx: ctx, done: make(chan struct{})}
+	go func() {
+		defer cancel()
+		start := time.Now()
+		err := c.fn(ctx)
+		res := Result{Status: StatusUp, Critical: c.critical, Duration: time.Since(start).String()}
+		if err != nil {
+			res.Status = StatusDown
+			res.Error = err.Error()
+		}
+		c.mu.Lock()
+		c.result, c.checked, c.running = res, time.Now(), nil
+		c.mu.Unlock()
+		cl.result = res
+		close(cl.done)
+	}()
+	return cl
+}
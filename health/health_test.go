@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRegistry(timeout, cacheTTL time.Duration) *Registry {
+	r := NewRegistry()
+	r.timeout, r.cacheTTL = timeout, cacheTTL
+	return r
+}
+
+func TestRegistryAggregatesCriticalChecks(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	tests := []struct {
+		name   string
+		checks map[string]CheckFunc
+		opts   []CheckOption
+		want   string
+	}{
+		{"no checks", nil, nil, StatusUp},
+		{"all up", map[string]CheckFunc{"database": up, "cache": up}, nil, StatusUp},
+		{"critical down", map[string]CheckFunc{"database": down, "cache": up}, nil, StatusDown},
+		{"non-critical down", map[string]CheckFunc{"database": down}, []CheckOption{Critical(false)}, StatusUp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRegistry(time.Second, 0)
+			for name, fn := range tt.checks {
+				r.Register(name, fn, tt.opts...)
+			}
+			report := r.Run(context.Background())
+			if report.Status != tt.want || len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %+v, want %s", report, tt.want)
+			}
+			r.SetStarted()
+			if ready, _ := r.Ready(context.Background()); ready != (tt.want == StatusUp) {
+				t.Errorf("got ready %v", ready)
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterAndUnregister(t *testing.T) {
+	r := newTestRegistry(time.Second, 0)
+	r.Register("database", func(context.Context) error { return nil })
+	r.Register("cache", func(context.Context) error { return nil })
+	r.Register("database", func(context.Context) error { return errors.New("down") })
+	if got := r.Names(); !slices.Equal(got, []string{"cache", "database"}) {
+		t.Errorf("got %v", got)
+	}
+	if report := r.Run(context.Background()); report.Checks["database"].Status != StatusDown {
+		t.Errorf("registered check is not replaced: %+v", report)
+	}
+	r.Unregister("database")
+	if got := r.Names(); !slices.Equal(got, []string{"cache"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestRegistryReadiness(t *testing.T) {
+	r := newTestRegistry(time.Second, 0)
+	if ready, _ := r.Ready(context.Background()); ready {
+		t.Error("ready before started")
+	}
+	r.SetStarted()
+	if ready, _ := r.Ready(context.Background()); !ready {
+		t.Error("not ready once started")
+	}
+	r.SetStopping()
+	if ready, _ := r.Ready(context.Background()); ready {
+		t.Error("ready while stopping")
+	}
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	r := newTestRegistry(time.Second, time.Hour)
+	r.Register("database", func(context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+	for range 3 {
+		r.Run(context.Background())
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls within the TTL, want 1", got)
+	}
+
+	r.cacheTTL = 0
+	r.Run(context.Background())
+	if got := calls.Load(); got != 2 {
+		t.Errorf("got %d calls once the TTL expired, want 2", got)
+	}
+}
+
+func TestRegistryDoesNotPileUpHungChecks(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	r := newTestRegistry(20*time.Millisecond, 0)
+	// The check ignores its context.
+	r.Register("hung", func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	})
+
+	for range 3 {
+		start := time.Now()
+		report := r.Run(context.Background())
+		if report.Status != StatusDown {
+			t.Fatalf("got %+v for a hung check", report)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("probe took %s", elapsed)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls of the hung check, want 1", got)
+	}
+
+	close(release)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Run(context.Background()).Status != StatusUp {
+		if time.Now().After(deadline) {
+			t.Fatal("check is not run again once it returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
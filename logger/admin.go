@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/web/problem"
+	"go.uber.org/zap"
+)
+
+// RegisterAdminRoutes registers the log level endpoints on the given router group.
+//
+//	GET /loggers        lists the root and named loggers and their level
+//	GET /loggers/:name  returns the level of a logger
+//	PUT /loggers/:name  sets the level of a logger, {"level": "debug"}; an empty level
+//	                    makes a named logger follow the root level again
+func RegisterAdminRoutes(router gin.IRoutes) {
+	router.GET("/loggers", listLevelsHandler)
+	router.GET("/loggers/:name", getLevelHandler)
+	router.PUT("/loggers/:name", setLevelHandler)
+}
+
+func listLevelsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, Levels())
+}
+
+func getLevelHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, GetLevel(c.Param("name")))
+}
+
+func setLevelHandler(c *gin.Context) {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := c.Param("name")
+	if err := SetLevel(name, req.Level); err != nil {
+		problem.Abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	DefaultLogger.Info("Log level changed", zap.String("logger", name), zap.String("level", req.Level))
+	c.JSON(http.StatusOK, GetLevel(name))
+}
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+const (
+	defaultHTTPBatchSize     = 100
+	defaultHTTPFlushInterval = 5 * time.Second
+	defaultHTTPTimeout       = 10 * time.Second
+	// maxHTTPBufferedBatches bounds the entries kept while the endpoint is unreachable.
+	maxHTTPBufferedBatches = 10
+)
+
+// httpWriter batches JSON log entries and posts them as a JSON array to an HTTP endpoint.
+// Entries are sent in the background, so logging never waits for the endpoint.
+type httpWriter struct {
+	url       string
+	headers   map[string]string
+	client    *http.Client
+	batchSize int
+
+	mu      sync.Mutex
+	entries [][]byte
+	dropped int
+
+	sendMu sync.Mutex
+	flush  chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	// ctx is the context of the requests, cancelled to abort them when the writer is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newHTTPWriter(url string, headers map[string]string, cfg config.LogHTTPConfig) *httpWriter {
+	w := &httpWriter{
+		url:       url,
+		headers:   headers,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		batchSize: cfg.BatchSize,
+		flush:     make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	if w.client.Timeout <= 0 {
+		w.client.Timeout = defaultHTTPTimeout
+	}
+	if w.batchSize <= 0 {
+		w.batchSize = defaultHTTPBatchSize
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = defaultHTTPFlushInterval
+	}
+
+	w.wg.Add(1)
+	go w.run(interval)
+	return w
+}
+
+// Write implements zapcore.WriteSyncer. p is a single encoded entry.
+func (w *httpWriter) Write(p []byte) (int, error) {
+	entry := bytes.TrimRight(p, "\n")
+	w.mu.Lock()
+	if len(w.entries) >= w.batchSize*maxHTTPBufferedBatches {
+		w.dropped++
+		w.mu.Unlock()
+		return len(p), nil
+	}
+	w.entries = append(w.entries, append([]byte(nil), entry...))
+	full := len(w.entries) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flush <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer. It sends the buffered entries.
+func (w *httpWriter) Sync() error {
+	return w.send()
+}
+
+// Close stops the background sender and sends the remaining entries. Requests still running
+// when ctx is done are aborted, and the remaining entries are lost.
+func (w *httpWriter) Close(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, w.cancel)
+	defer stop()
+	defer w.cancel()
+
+	close(w.done)
+	w.wg.Wait()
+	if err := w.send(); err != nil {
+		return errors.Join(err, ctx.Err())
+	}
+	return nil
+}
+
+func (w *httpWriter) run(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		case <-w.flush:
+		}
+		if err := w.send(); err != nil {
+			// The logger cannot log its own failures.
+			fmt.Fprintf(os.Stderr, "failed to ship logs to %s: %v\n", w.url, err)
+		}
+	}
+}
+
+// send posts the buffered entries. Entries are put back if the endpoint cannot be reached.
+func (w *httpWriter) send() error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	w.mu.Lock()
+	entries, dropped := w.entries, w.dropped
+	w.entries, w.dropped = nil, 0
+	w.mu.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "dropped %d log entries for %s\n", dropped, w.url)
+	}
+
+	body := append([]byte{'['}, bytes.Join(entries, []byte{','})...)
+	body = append(body, ']')
+	err := w.post(body)
+	if err != nil {
+		w.mu.Lock()
+		if len(entries)+len(w.entries) <= w.batchSize*maxHTTPBufferedBatches {
+			w.entries = append(entries, w.entries...)
+		}
+		w.mu.Unlock()
+	}
+	return err
+}
+
+func (w *httpWriter) post(body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+func TestHTTPWriterCloseAbortsOnContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	w := newHTTPWriter(server.URL, nil, config.LogHTTPConfig{Timeout: time.Minute, FlushInterval: time.Hour})
+	w.Write([]byte(`{"msg":"message"}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := w.Close(ctx); err == nil {
+		t.Error("got no error for an unreachable endpoint")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %s", elapsed)
+	}
+}
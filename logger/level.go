@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RootLogger is the name of the root logger in SetLevel and Levels.
+const RootLogger = "root"
+
+// DefaultLevel is the level of the root logger. Named loggers follow it unless their level is set.
+var DefaultLevel = zap.NewAtomicLevel()
+
+var (
+	levelsMu sync.RWMutex
+	levels   = make(map[string]*namedLevel)
+)
+
+// LevelInfo describes the level of a logger.
+type LevelInfo struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	// Inherited is true if the logger follows the root level.
+	Inherited bool `json:"inherited"`
+}
+
+// namedLevel is the level of a named logger, falling back to the root level when not set.
+type namedLevel struct {
+	level atomic.Pointer[zapcore.Level]
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (l *namedLevel) Enabled(lvl zapcore.Level) bool {
+	return l.Level().Enabled(lvl)
+}
+
+// Level returns the effective level of the logger.
+func (l *namedLevel) Level() zapcore.Level {
+	if lvl := l.level.Load(); lvl != nil {
+		return *lvl
+	}
+	return DefaultLevel.Level()
+}
+
+// levelOf returns the level of the named logger, creating it if needed.
+func levelOf(name string) *namedLevel {
+	levelsMu.RLock()
+	l, ok := levels[name]
+	levelsMu.RUnlock()
+	if ok {
+		return l
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	if l, ok := levels[name]; ok {
+		return l
+	}
+	l = &namedLevel{}
+	levels[name] = l
+	return l
+}
+
+// Named returns a child of l named name whose level can be changed independently with SetLevel.
+func Named(l *zap.Logger, name string) *zap.Logger {
+	level := levelOf(name)
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if lc, ok := core.(*levelCore); ok {
+			core = lc.Core
+		}
+		return &levelCore{Core: core, level: level}
+	})).Named(name)
+}
+
+// SetLevel changes the level of a logger at runtime. For a named logger, an empty level
+// makes it follow the root level again.
+func SetLevel(name, level string) error {
+	if name == RootLogger {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		DefaultLevel.SetLevel(lvl)
+		return nil
+	}
+	if level == "" {
+		levelOf(name).level.Store(nil)
+		return nil
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levelOf(name).level.Store(&lvl)
+	return nil
+}
+
+// Levels returns the levels of the root logger and of the named loggers, sorted by name.
+func Levels() []LevelInfo {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	infos := make([]LevelInfo, 0, len(levels)+1)
+	for name, l := range levels {
+		infos = append(infos, LevelInfo{Logger: name, Level: l.Level().String(), Inherited: l.level.Load() == nil})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Logger < infos[j].Logger
+	})
+	return append([]LevelInfo{{Logger: RootLogger, Level: DefaultLevel.String()}}, infos...)
+}
+
+// GetLevel returns the level of a logger.
+func GetLevel(name string) LevelInfo {
+	if name == RootLogger {
+		return LevelInfo{Logger: RootLogger, Level: DefaultLevel.String()}
+	}
+	levelsMu.RLock()
+	l, ok := levels[name]
+	levelsMu.RUnlock()
+	if !ok {
+		return LevelInfo{Logger: name, Level: DefaultLevel.String(), Inherited: true}
+	}
+	return LevelInfo{Logger: name, Level: l.Level().String(), Inherited: l.level.Load() == nil}
+}
+
+// levelCore filters the entries of a core by a level that can change at runtime.
+// The wrapped core is enabled for all levels so named loggers can be more verbose than the root.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+// Enabled implements zapcore.Core.
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+// With implements zapcore.Core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Check implements zapcore.Core.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
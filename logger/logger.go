@@ -1,25 +1,81 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/trinitytechnology/ebrick/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var DefaultLogger *zap.Logger
 
+var (
+	closersMu sync.Mutex
+	closers   []func(ctx context.Context) error
+)
+
 func init() {
 	environment := config.GetConfig().Env
 	DefaultLogger = NewLogger(environment)
+	config.OnReload(func(cfg *config.Config) {
+		if err := applyLevels(cfg.Env, cfg.Logger); err != nil {
+			DefaultLogger.Error("Failed to apply reloaded log levels", zap.Error(err))
+			return
+		}
+		DefaultLogger.Info("Log levels reloaded", zap.String("level", DefaultLevel.String()))
+	})
 }
 
+// NewLogger creates a logger writing to stderr and, if enabled in the logger configuration,
+// to the configured sink. Sampling and redaction apply to all outputs. The level of the
+// logger is DefaultLevel, set from the configuration, see SetLevel to change it at runtime.
 func NewLogger(env string) *zap.Logger {
-	config := zap.NewDevelopmentConfig()
+	cfg := config.GetConfig().Logger
+	if err := applyLevels(env, cfg); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	zapCfg := zap.NewDevelopmentConfig()
 	if env == "production" {
-		config = zap.NewProductionConfig()
+		zapCfg = zap.NewProductionConfig()
+	}
+	// Outputs accept all levels, entries are filtered by the root or named logger level.
+	zapCfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	// Sampling is applied on top of all outputs so the sink receives the same entries as stderr.
+	sampling := zapCfg.Sampling
+	if cfg.Sampling.Initial > 0 {
+		sampling = &zap.SamplingConfig{Initial: cfg.Sampling.Initial, Thereafter: cfg.Sampling.Thereafter}
 	}
-	zapLogger, err := config.Build()
+	zapCfg.Sampling = nil
+
+	var sink zapcore.Core
+	if cfg.Enable {
+		var closer func(ctx context.Context) error
+		var err error
+		sink, closer, err = newSink(cfg, zapCfg.Level)
+		if err != nil {
+			log.Fatalf("Failed to initialize logger: %v", err)
+		}
+		closersMu.Lock()
+		closers = append(closers, closer)
+		closersMu.Unlock()
+	}
+
+	zapLogger, err := zapCfg.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		core = newRedactCore(core, cfg.Redact)
+		if sink != nil {
+			core = zapcore.NewTee(core, newRedactCore(sink, cfg.Redact))
+		}
+		if sampling != nil {
+			core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+		}
+		return &levelCore{Core: core, level: DefaultLevel}
+	}))
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
@@ -27,3 +83,61 @@ func NewLogger(env string) *zap.Logger {
 	zapLogger.Info("Starting application", zap.String("env", env))
 	return zapLogger
 }
+
+// applyLevels sets the root level and the levels of named loggers from the configuration.
+// The root level defaults to debug in development and info otherwise, and named loggers
+// missing from the configuration follow the root level.
+func applyLevels(env string, cfg config.LoggerConfig) error {
+	level := cfg.Level
+	if level == "" {
+		level = zapcore.DebugLevel.String()
+		if env == "production" {
+			level = zapcore.InfoLevel.String()
+		}
+	}
+	if err := SetLevel(RootLogger, level); err != nil {
+		return err
+	}
+	levelsMu.RLock()
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	levelsMu.RUnlock()
+	for _, name := range names {
+		if _, ok := cfg.Levels[name]; !ok {
+			SetLevel(name, "")
+		}
+	}
+	for name, level := range cfg.Levels {
+		if err := SetLevel(name, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the loggers and releases their sinks, giving up when ctx is done. Entries
+// logged afterwards may be lost.
+func Close(ctx context.Context) error {
+	synced := make(chan struct{})
+	go func() {
+		DefaultLogger.Sync()
+		close(synced)
+	}()
+	select {
+	case <-synced:
+	case <-ctx.Done():
+	}
+
+	closersMu.Lock()
+	defer closersMu.Unlock()
+	var errs []error
+	for _, closer := range closers {
+		if err := closer(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	closers = nil
+	return errors.Join(errs...)
+}
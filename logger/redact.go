@@ -0,0 +1,341 @@
+package logger
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redactedValue = "[REDACTED]"
+
+// redactCore replaces the values of fields whose key is in keys, ignoring case, at any depth:
+// nested keys of objects, arrays and reflected values such as maps and structs are redacted too.
+type redactCore struct {
+	zapcore.Core
+	keys redactKeys
+}
+
+// redactKeys is the set of lower-cased keys whose values are redacted.
+type redactKeys map[string]struct{}
+
+func (k redactKeys) has(key string) bool {
+	_, ok := k[strings.ToLower(key)]
+	return ok
+}
+
+// newRedactCore wraps core, or returns it as is if there is nothing to redact.
+func newRedactCore(core zapcore.Core, keys []string) zapcore.Core {
+	if len(keys) == 0 {
+		return core
+	}
+	c := &redactCore{Core: core, keys: make(redactKeys, len(keys))}
+	for _, key := range keys {
+		c.keys[strings.ToLower(key)] = struct{}{}
+	}
+	return c
+}
+
+// With implements zapcore.Core.
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redact(fields)), keys: c.keys}
+}
+
+// Check implements zapcore.Core.
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write implements zapcore.Core.
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.redact(fields))
+}
+
+// redact returns the fields with the values of the redacted keys replaced, without modifying fields.
+func (c *redactCore) redact(fields []zapcore.Field) []zapcore.Field {
+	var redacted []zapcore.Field
+	for i, f := range fields {
+		r, ok := c.keys.field(f)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]zapcore.Field, len(fields))
+			copy(redacted, fields)
+		}
+		redacted[i] = r
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// field returns the field with its redacted keys replaced, and whether it may hold one.
+func (k redactKeys) field(f zapcore.Field) (zapcore.Field, bool) {
+	if k.has(f.Key) {
+		return zap.String(f.Key, redactedValue), true
+	}
+	switch f.Type {
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		f.Interface = redactObject{f.Interface.(zapcore.ObjectMarshaler), k}
+	case zapcore.ArrayMarshalerType:
+		f.Interface = redactArray{f.Interface.(zapcore.ArrayMarshaler), k}
+	case zapcore.ReflectType:
+		f.Interface = k.reflected(f.Interface)
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+// reflected returns a reflected value with its redacted keys replaced. Maps, structs and slices
+// are converted to their JSON representation to be redacted; other values are returned as is.
+func (k redactKeys) reflected(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return v
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+	default:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return v
+	}
+	return k.json(decoded)
+}
+
+// json replaces the values of the redacted keys in a decoded JSON value.
+func (k redactKeys) json(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if k.has(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = k.json(val)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = k.json(val)
+		}
+	}
+	return v
+}
+
+// redactObject marshals an object with its redacted keys replaced.
+type redactObject struct {
+	zapcore.ObjectMarshaler
+	keys redactKeys
+}
+
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.ObjectMarshaler.MarshalLogObject(redactObjectEncoder{enc, o.keys})
+}
+
+// redactArray marshals an array with the redacted keys of its elements replaced.
+type redactArray struct {
+	zapcore.ArrayMarshaler
+	keys redactKeys
+}
+
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.ArrayMarshaler.MarshalLogArray(redactArrayEncoder{enc, a.keys})
+}
+
+// redactArrayEncoder redacts the objects, arrays and reflected values appended to an array.
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	keys redactKeys
+}
+
+func (e redactArrayEncoder) AppendArray(v zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArray{v, e.keys})
+}
+
+func (e redactArrayEncoder) AppendObject(v zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObject{v, e.keys})
+}
+
+func (e redactArrayEncoder) AppendReflected(v any) error {
+	return e.ArrayEncoder.AppendReflected(e.keys.reflected(v))
+}
+
+// redactObjectEncoder replaces the values of the redacted keys added to an object.
+type redactObjectEncoder struct {
+	zapcore.ObjectEncoder
+	keys redactKeys
+}
+
+// redacted adds the redacted value of key and reports whether key is redacted.
+func (e redactObjectEncoder) redacted(key string) bool {
+	if !e.keys.has(key) {
+		return false
+	}
+	e.ObjectEncoder.AddString(key, redactedValue)
+	return true
+}
+
+func (e redactObjectEncoder) AddArray(key string, v zapcore.ArrayMarshaler) error {
+	if e.redacted(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, redactArray{v, e.keys})
+}
+
+func (e redactObjectEncoder) AddObject(key string, v zapcore.ObjectMarshaler) error {
+	if e.redacted(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactObject{v, e.keys})
+}
+
+func (e redactObjectEncoder) AddReflected(key string, v any) error {
+	if e.redacted(key) {
+		return nil
+	}
+	return e.ObjectEncoder.AddReflected(key, e.keys.reflected(v))
+}
+
+func (e redactObjectEncoder) AddBinary(key string, v []byte) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddBinary(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddByteString(key string, v []byte) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddByteString(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddBool(key string, v bool) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddBool(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddComplex128(key string, v complex128) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddComplex128(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddComplex64(key string, v complex64) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddComplex64(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddDuration(key string, v time.Duration) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddDuration(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddFloat64(key string, v float64) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddFloat64(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddFloat32(key string, v float32) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddFloat32(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddInt(key string, v int) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddInt(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddInt64(key string, v int64) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddInt64(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddInt32(key string, v int32) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddInt32(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddInt16(key string, v int16) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddInt16(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddInt8(key string, v int8) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddInt8(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddString(key, v string) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddString(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddTime(key string, v time.Time) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddTime(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUint(key string, v uint) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUint(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUint64(key string, v uint64) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUint64(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUint32(key string, v uint32) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUint32(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUint16(key string, v uint16) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUint16(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUint8(key string, v uint8) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUint8(key, v)
+	}
+}
+
+func (e redactObjectEncoder) AddUintptr(key string, v uintptr) {
+	if !e.redacted(key) {
+		e.ObjectEncoder.AddUintptr(key, v)
+	}
+}
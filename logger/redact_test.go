@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type credentials struct {
+	User     string
+	Password string
+}
+
+func (c credentials) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("user", c.User)
+	enc.AddString("password", c.Password)
+	return nil
+}
+
+// account is logged as a reflected value.
+type account struct {
+	User     string
+	Password string `json:"password"`
+}
+
+func TestRedactCore(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.DebugLevel)
+	log := zap.New(newRedactCore(core, []string{"Password", "token"}))
+
+	log.With(zap.String("token", "secret-with")).Info("message",
+		zap.String("password", "secret-top"),
+		zap.Object("login", credentials{User: "alice", Password: "secret-object"}),
+		zap.Objects("logins", []credentials{{User: "bob", Password: "secret-array"}}),
+		zap.Any("request", map[string]any{"headers": map[string]string{"Token": "secret-map"}, "path": "/login"}),
+		zap.Any("struct", account{User: "carol", Password: "secret-struct"}),
+		zap.Int("count", 3),
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "secret") {
+		t.Errorf("secrets are logged: %s", out)
+	}
+	for _, want := range []string{`"user":"alice"`, `"user":"bob"`, `"path":"/login"`, `"User":"carol"`, `"count":3`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s: %s", want, out)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trinitytechnology/ebrick/config"
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	SinkOTLP = "otlp"
+	SinkFile = "file"
+	SinkHTTP = "http"
+)
+
+// newSink creates the core shipping logs to the configured sink, and the function releasing it.
+func newSink(cfg config.LoggerConfig, level zap.AtomicLevel) (zapcore.Core, func(ctx context.Context) error, error) {
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+
+	switch cfg.Type {
+	case SinkOTLP:
+		opts := []otlploggrpc.Option{otlploggrpc.WithInsecure(), otlploggrpc.WithEndpoint(cfg.Endpoint)}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlploggrpc.WithHeaders(cfg.Headers))
+		}
+		exporter, err := otlploggrpc.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		serviceName := config.GetConfig().Service.Name
+		provider := sdklog.NewLoggerProvider(
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+			sdklog.WithResource(resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceNameKey.String(serviceName),
+			)),
+		)
+		core, err := zapcore.NewIncreaseLevelCore(otelzap.NewCore(serviceName, otelzap.WithLoggerProvider(provider)), level)
+		if err != nil {
+			return nil, nil, err
+		}
+		return core, provider.Shutdown, nil
+
+	case SinkFile:
+		if cfg.Endpoint == "" {
+			return nil, nil, fmt.Errorf("file log sink requires the path of the file as endpoint")
+		}
+		file := &lumberjack.Logger{
+			Filename:   cfg.Endpoint,
+			MaxSize:    cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAge,
+			Compress:   cfg.File.Compress,
+		}
+		closer := func(ctx context.Context) error {
+			return file.Close()
+		}
+		return zapcore.NewCore(encoder, zapcore.AddSync(file), level), closer, nil
+
+	case SinkHTTP:
+		if cfg.Endpoint == "" {
+			return nil, nil, fmt.Errorf("http log sink requires the URL as endpoint")
+		}
+		writer := newHTTPWriter(cfg.Endpoint, cfg.Headers, cfg.HTTP)
+		return zapcore.NewCore(encoder, writer, level), writer.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("invalid log sink type: %s", cfg.Type)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trinitytechnology/ebrick/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewSinkFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	core, closer, err := newSink(config.LoggerConfig{Type: SinkFile, Endpoint: path}, zap.NewAtomicLevelAt(zapcore.WarnLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zap.New(core)
+	log.Info("filtered")
+	log.Warn("written", zap.String("module", "billing"))
+	if err := closer(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := string(data); !strings.Contains(out, `"msg":"written","module":"billing"`) || strings.Contains(out, "filtered") {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestNewSinkHTTP(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r.Header.Get("X-Api-Key") + " " + string(body)
+	}))
+	defer server.Close()
+
+	cfg := config.LoggerConfig{Type: SinkHTTP, Endpoint: server.URL, Headers: map[string]string{"X-Api-Key": "key"},
+		HTTP: config.LogHTTPConfig{FlushInterval: time.Hour}}
+	core, closer, err := newSink(cfg, zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zap.New(core).Info("shipped")
+	if err := closer(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if !strings.HasPrefix(got, "key ") || !strings.Contains(got, `"msg":"shipped"`) {
+			t.Errorf("got %s", got)
+		}
+	default:
+		t.Error("remaining entries are not sent on close")
+	}
+}
+
+func TestNewSinkOTLP(t *testing.T) {
+	// The exporter connects lazily, so the sink is created without a collector.
+	core, closer, err := newSink(config.LoggerConfig{Type: SinkOTLP, Endpoint: "127.0.0.1:1"}, zap.NewAtomicLevelAt(zapcore.WarnLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if core.Enabled(zapcore.InfoLevel) || !core.Enabled(zapcore.WarnLevel) {
+		t.Error("sink does not follow the level")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	closer(ctx)
+}
+
+func TestNewSinkInvalid(t *testing.T) {
+	for _, cfg := range []config.LoggerConfig{
+		{Type: SinkFile},
+		{Type: SinkHTTP},
+		{Type: "syslog", Endpoint: "localhost:514"},
+		{},
+	} {
+		if _, _, err := newSink(cfg, zap.NewAtomicLevel()); err == nil {
+			t.Errorf("got no error for %+v", cfg)
+		}
+	}
+}
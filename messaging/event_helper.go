@@ -1,13 +1,18 @@
 package messaging
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/observability"
 )
 
+// RequestIDExtension is the CloudEvents extension carrying the ID of the request an event was published from.
+const RequestIDExtension = "requestid"
+
 func GenerateConsumerName(name string) string {
 	return fmt.Sprintf("%s-%s", name, uuid.NewString())
 }
@@ -21,3 +26,21 @@ func CreateEvent(eventSource string, eventType EventType, data any) event.Event
 	ev.SetID(uuid.NewString())
 	return ev
 }
+
+// setRequestID sets the request ID carried by ctx on the event, unless the event already has one.
+func setRequestID(ctx context.Context, ev *event.Event) {
+	if _, ok := ev.Extensions()[RequestIDExtension]; ok {
+		return
+	}
+	if id := observability.RequestIDFromContext(ctx); id != "" {
+		ev.SetExtension(RequestIDExtension, id)
+	}
+}
+
+// contextWithRequestID returns ctx carrying the request ID of the event, if it has one.
+func contextWithRequestID(ctx context.Context, ev *event.Event) context.Context {
+	if id, ok := ev.Extensions()[RequestIDExtension].(string); ok && id != "" {
+		return observability.WithRequestID(ctx, id)
+	}
+	return ctx
+}
@@ -0,0 +1,34 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/trinitytechnology/ebrick/observability"
+)
+
+func TestRequestIDPropagation(t *testing.T) {
+	ev := CreateEvent("orders", "order.created", map[string]string{"id": "1"})
+	setRequestID(observability.WithRequestID(context.Background(), "req-1"), &ev)
+	if got := ev.Extensions()[RequestIDExtension]; got != "req-1" {
+		t.Fatalf("got %v, want req-1", got)
+	}
+
+	// The request ID of an event is not replaced when it is republished.
+	setRequestID(observability.WithRequestID(context.Background(), "req-2"), &ev)
+	if got := observability.RequestIDFromContext(contextWithRequestID(context.Background(), &ev)); got != "req-1" {
+		t.Errorf("got %q, want req-1", got)
+	}
+}
+
+func TestRequestIDPropagationWithoutRequestID(t *testing.T) {
+	ev := CreateEvent("orders", "order.created", nil)
+	setRequestID(context.Background(), &ev)
+	if _, ok := ev.Extensions()[RequestIDExtension]; ok {
+		t.Error("got a request ID extension without a request ID")
+	}
+	ctx := context.Background()
+	if contextWithRequestID(ctx, &ev) != ctx {
+		t.Error("got a new context without a request ID")
+	}
+}
@@ -21,6 +21,10 @@ type CloudEventStream interface {
 	SubscribeDLQ(topic string, handler func(msg any, ctx context.Context) error) error
 	CreateStream(stream string, topics []string) error
 	CreateConsumerGroup(stream, name string, config ConsumerConfig) error
+	// Unsubscribe stops the subscriptions created for the topic and group.
+	// An empty group refers to the subscriptions created by SubscribeDLQ.
+	// Streams implementing Subscriber can stop a single subscription.
+	Unsubscribe(topic, group string) error
 	Close() error
 }
 
@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/trinitytechnology/ebrick/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	opPublish = "publish"
+	opConsume = "consume"
+	opAck     = "ack"
+	opNak     = "nak"
+	opRetry   = "retry"
+	opDLQ     = "dlq"
+	opError   = "error"
+
+	systemNats  = "nats"
+	systemRedis = "redis-stream"
+)
+
+// streamMetrics counts the messages going through an event stream by operation.
+type streamMetrics struct {
+	messages metric.Int64Counter
+	system   attribute.KeyValue
+}
+
+func newStreamMetrics(system string) *streamMetrics {
+	messages, err := observability.InternalMeter("messaging").Int64Counter("messaging.messages",
+		metric.WithUnit("{message}"),
+		metric.WithDescription("Number of messages by operation (publish, consume, ack, nak, retry, dlq or error)."))
+	if err != nil {
+		log.Error("Failed to create messaging metrics", zap.Error(err))
+	}
+	return &streamMetrics{messages: messages, system: attribute.String("messaging.system", system)}
+}
+
+// add counts a message of the operation on the topic. The group is empty for publishing.
+func (m *streamMetrics) add(ctx context.Context, operation, topic, group string) {
+	m.messages.Add(ctx, 1, metric.WithAttributes(
+		m.system,
+		attribute.String("messaging.operation", operation),
+		attribute.String("messaging.destination.name", topic),
+		attribute.String("messaging.consumer.group.name", group),
+	))
+}
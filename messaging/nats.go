@@ -3,11 +3,13 @@ package messaging
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/nats-io/nats.go"
 	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/observability"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/zap"
@@ -37,9 +39,10 @@ func initNats(opt *Options) (*nats.Conn, nats.JetStreamContext) {
 }
 
 type natsJetStream struct {
-	conn *nats.Conn
-	js   nats.JetStreamContext
-	subs []*nats.Subscription
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subs    *subscriptions
+	metrics *streamMetrics
 }
 
 // CreateStream creates a JetStream stream with the specified name and subjects.
@@ -69,22 +72,18 @@ func (n *natsJetStream) CreateConsumerGroup(stream, name string, config Consumer
 
 // Close unsubscribes from all JetStream subscriptions and closes the NATS connection.
 func (n *natsJetStream) Close() error {
-	var errs []error
-	for _, sub := range n.subs {
-		if err := sub.Unsubscribe(); err != nil {
-			errs = append(errs, err)
-		}
-	}
+	err := n.subs.removeAll()
 	n.conn.Close()
 
-	if len(errs) > 0 {
+	if err != nil {
 		return errors.New("failed to close all subscriptions or connection")
 	}
 	return nil
 }
 
-// Publish publishes a CloudEvent to a JetStream subject with tracing context.
+// Publish publishes a CloudEvent to a JetStream subject with tracing context and the request ID of ctx.
 func (n *natsJetStream) Publish(subject string, ctx context.Context, ev event.Event) error {
+	setRequestID(ctx, &ev)
 	data, err := ev.MarshalJSON()
 	if err != nil {
 		log.Error("failed to marshal event", zap.Error(err))
@@ -94,6 +93,7 @@ func (n *natsJetStream) Publish(subject string, ctx context.Context, ev event.Ev
 
 	// Check if tracing is enabled
 	cfg := config.GetConfig().Observability
+	ctx, span := startPublishSpan(ctx, systemNats, subject, &ev)
 	if cfg.Tracing.Enable {
 		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(headers))
 	}
@@ -103,14 +103,26 @@ func (n *natsJetStream) Publish(subject string, ctx context.Context, ev event.Ev
 		Data:    data,
 		Header:  headers,
 	})
-	return err
+	observability.EndSpan(span, err)
+	if err != nil {
+		n.metrics.add(ctx, opError, subject, "")
+		return err
+	}
+	n.metrics.add(ctx, opPublish, subject, "")
+	return nil
 }
 
 // Subscribe subscribes to a JetStream subject and processes incoming CloudEvents with the provided handler.
 func (n *natsJetStream) Subscribe(subject, group string, handler func(ev *event.Event, ctx context.Context) error) error {
+	_, err := n.SubscribeHandle(subject, group, handler)
+	return err
+}
+
+// SubscribeHandle implements Subscriber.
+func (n *natsJetStream) SubscribeHandle(subject, group string, handler func(ev *event.Event, ctx context.Context) error) (Subscription, error) {
 	// Check if the group parameter is empty
 	if group == "" {
-		return errors.New("group cannot be empty")
+		return nil, errors.New("group cannot be empty")
 	} else {
 		sub, err := n.js.QueueSubscribe(subject, group, func(msg *nats.Msg) {
 
@@ -122,56 +134,105 @@ func (n *natsJetStream) Subscribe(subject, group string, handler func(ev *event.
 				ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(msg.Header))
 			}
 
+			n.metrics.add(ctx, opConsume, subject, group)
+			retries := 0
+			if meta, err := msg.Metadata(); err == nil && meta.NumDelivered > 1 {
+				retries = int(meta.NumDelivered) - 1
+				n.metrics.add(ctx, opRetry, subject, group)
+			}
+
 			var ev event.Event
 			if err := ev.UnmarshalJSON(msg.Data); err != nil {
 				log.Error("failed to unmarshal event", zap.Error(err))
 				msg.Nak()
+				n.metrics.add(ctx, opNak, subject, group)
 				return
 			}
 
-			if err := handler(&ev, ctx); err != nil {
+			ctx = contextWithRequestID(ctx, &ev)
+			ctx, span := startProcessSpan(ctx, systemNats, subject, group, &ev, retries)
+			err := handler(&ev, ctx)
+			observability.EndSpan(span, err)
+			if err != nil {
 				log.Error("failed to process event", zap.Error(err))
 				msg.Nak()
+				n.metrics.add(ctx, opNak, subject, group)
 				return
 			}
 			msg.Ack()
+			n.metrics.add(ctx, opAck, subject, group)
 		}, nats.Durable(group), nats.ManualAck())
 
 		if err != nil {
 			log.Error("failed to subscribe to NATS JetStream", zap.Error(err))
-			return err
+			return nil, err
 		}
 		log.Info("Successfully subscribed to subject", zap.String("subject", subject), zap.String("group", group))
-		n.subs = append(n.subs, sub)
-		return nil
+		return n.subs.add(subscriptionKey{topic: subject, group: group}, sub.Unsubscribe), nil
 	}
 
 }
 
 // SubscribeDLQ implements CloudEventStream.
 func (n *natsJetStream) SubscribeDLQ(subject string, handler func(msg any, ctx context.Context) error) error {
+	_, err := n.SubscribeDLQHandle(subject, handler)
+	return err
+}
+
+// SubscribeDLQHandle implements Subscriber.
+func (n *natsJetStream) SubscribeDLQHandle(subject string, handler func(msg any, ctx context.Context) error) (Subscription, error) {
 	log.Info("Subscribing to NATS JetStream", zap.String("subject", subject))
-	_, err := n.js.Subscribe(subject, func(msg *nats.Msg) {
-		if err := handler(msg.Data, nil); err != nil {
+	sub, err := n.js.Subscribe(subject, func(msg *nats.Msg) {
+		ctx := context.Background()
+		if config.GetConfig().Observability.Tracing.Enable {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(msg.Header))
+		}
+		n.metrics.add(ctx, opDLQ, subject, "")
+		ctx, span := startProcessSpan(ctx, systemNats, subject, "", nil, 0)
+		err := handler(msg.Data, ctx)
+		observability.EndSpan(span, err)
+		if err != nil {
 			log.Error("failed to process event", zap.Error(err))
 			msg.Nak()
+			n.metrics.add(ctx, opNak, subject, "")
 			return
 		}
 		msg.Ack()
+		n.metrics.add(ctx, opAck, subject, "")
 	}, nats.ManualAck())
 
 	if err != nil {
 		log.Error("failed to subscribe to NATS JetStream", zap.Error(err))
-		return err
+		return nil, err
+	}
+	return n.subs.add(subscriptionKey{topic: subject, dlq: true}, sub.Unsubscribe), nil
+}
+
+// HealthCheck reports an error if the NATS connection is not established.
+func (n *natsJetStream) HealthCheck(ctx context.Context) error {
+	if status := n.conn.Status(); status != nats.CONNECTED {
+		return fmt.Errorf("NATS connection is %s", status)
 	}
 	return nil
 }
 
+// Unsubscribe implements CloudEventStream.
+func (n *natsJetStream) Unsubscribe(subject, group string) error {
+	found, err := n.subs.remove(subscriptionKey{topic: subject, group: group, dlq: group == ""})
+	if !found {
+		return fmt.Errorf("no subscription for subject %s and group %s", subject, group)
+	}
+	log.Info("Unsubscribing from subject", zap.String("subject", subject), zap.String("group", group))
+	return err
+}
+
 func NewNatsJetStream(opts ...Option) CloudEventStream {
 	opt := newOptions(opts...)
 	conn, js := initNats(opt)
 	return &natsJetStream{
-		conn: conn,
-		js:   js,
+		conn:    conn,
+		js:      js,
+		subs:    newSubscriptions(),
+		metrics: newStreamMetrics(systemNats),
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/redis/rueidis"
 	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/observability"
 	"github.com/trinitytechnology/ebrick/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
@@ -19,7 +20,10 @@ import (
 type redisStream struct {
 	client           rueidis.Client
 	ctx              context.Context
+	cancel           context.CancelFunc
 	consumer_configs map[string]ConsumerConfig
+	subs             *subscriptions
+	metrics          *streamMetrics
 }
 
 // DefaultConsumerConfig provides default values for ConsumerConfig.
@@ -48,10 +52,14 @@ func NewRedisStream() CloudEventStream {
 	client := InitRedisClient()
 	log.Info("Connected to Redis", zap.String("url", redisURL))
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &redisStream{
 		client:           *client,
-		ctx:              context.Background(),
+		ctx:              ctx,
+		cancel:           cancel,
 		consumer_configs: make(map[string]ConsumerConfig),
+		subs:             newSubscriptions(),
+		metrics:          newStreamMetrics(systemRedis),
 	}
 }
 
@@ -81,13 +89,17 @@ func (r *redisStream) CreateConsumerGroup(stream, group string, config ConsumerC
 	return nil
 }
 
-// Close performs any necessary cleanup for the redisStream.
+// Close stops all running consumers and closes the Redis client.
 func (r *redisStream) Close() error {
+	r.subs.removeAll()
+	r.cancel()
+	r.client.Close()
 	return nil
 }
 
-// Publish sends an event to the specified stream and adds tracing information if enabled.
+// Publish sends an event to the specified stream with the request ID of ctx and adds tracing information if enabled.
 func (r *redisStream) Publish(stream string, ctx context.Context, ev event.Event) error {
+	setRequestID(ctx, &ev)
 	data, err := ev.MarshalJSON()
 	if err != nil {
 		log.Error("failed to marshal event", zap.Error(err))
@@ -96,6 +108,7 @@ func (r *redisStream) Publish(stream string, ctx context.Context, ev event.Event
 
 	headers := make(map[string]string)
 	cfg := config.GetConfig().Observability
+	ctx, span := startPublishSpan(ctx, systemRedis, stream, &ev)
 	if cfg.Tracing.Enable {
 		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
 	}
@@ -108,16 +121,25 @@ func (r *redisStream) Publish(stream string, ctx context.Context, ev event.Event
 	}
 
 	resp := r.client.Do(r.ctx, builder.Build())
+	observability.EndSpan(span, resp.Error())
 	if resp.Error() != nil {
+		r.metrics.add(ctx, opError, stream, "")
 		return fmt.Errorf("failed to add message to stream: %w", resp.Error())
 	}
+	r.metrics.add(ctx, opPublish, stream, "")
 	return nil
 }
 
 // Subscribe sets up a consumer to process messages from a stream using the specified group.
 func (r *redisStream) Subscribe(stream, group string, handler func(ev *event.Event, ctx context.Context) error) error {
+	_, err := r.SubscribeHandle(stream, group, handler)
+	return err
+}
+
+// SubscribeHandle implements Subscriber.
+func (r *redisStream) SubscribeHandle(stream, group string, handler func(ev *event.Event, ctx context.Context) error) (Subscription, error) {
 	if group == "" {
-		return errors.New("group cannot be empty")
+		return nil, errors.New("group cannot be empty")
 	}
 
 	// Fetch consumer configuration, use defaults if not found
@@ -129,22 +151,30 @@ func (r *redisStream) Subscribe(stream, group string, handler func(ev *event.Eve
 	}
 
 	if err := r.CreateConsumerGroup(stream, group, config); err != nil {
-		return fmt.Errorf("error creating consumer group: %w", err)
+		return nil, fmt.Errorf("error creating consumer group: %w", err)
 	}
 
+	subCtx, sub := r.addSubscription(subscriptionKey{topic: stream, group: group})
 	go func() {
 		for {
-			msgId, ev, err := r.ConsumeMessages(group, GenerateConsumerName(group), ">", 10, 0, stream)
+			msgCtx, msgId, ev, err := r.ConsumeMessages(subCtx, group, GenerateConsumerName(group), ">", 10, 0, stream)
+			if subCtx.Err() != nil {
+				return // Unsubscribed or stream closed
+			}
 			if err != nil {
 				log.Error("Error consuming messages from stream", zap.Error(err))
 				continue
 			}
+			r.metrics.add(subCtx, opConsume, stream, group)
 
 			attempts := 0
 			for {
-				err := handler(&ev, r.ctx)
+				ctx, span := startProcessSpan(msgCtx, systemRedis, stream, group, &ev, attempts)
+				err := handler(&ev, ctx)
+				observability.EndSpan(span, err)
 				if err == nil {
 					r.ackMsg(stream, group, msgId)
+					r.metrics.add(subCtx, opAck, stream, group)
 					break // Successful processing
 				}
 
@@ -153,23 +183,30 @@ func (r *redisStream) Subscribe(stream, group string, handler func(ev *event.Eve
 
 				if attempts >= config.MaxDeliver {
 					log.Error("Max retries exceeded, sending to DLQ", zap.String("msgId", msgId))
-					r.PublishDLQ(ev, config.DeliverSubject)
+					if err := r.PublishDLQ(ev, config.DeliverSubject); err != nil {
+						// The message stays pending in the group rather than being lost.
+						log.Error("Failed to send message to DLQ", zap.String("msgId", msgId), zap.Error(err))
+						break
+					}
 					r.ackMsg(stream, group, msgId)
+					r.metrics.add(subCtx, opDLQ, stream, group)
 					break
 				}
+				r.metrics.add(subCtx, opRetry, stream, group)
 				time.Sleep(config.AckWait) // Use the configured wait time
 			}
 		}
 	}()
 
 	log.Info("Successfully subscribed to stream", zap.String("stream", stream), zap.String("group", group))
-	return nil
+	return sub, nil
 }
 
 // ConsumeMessages reads messages from a specified group and streams; returns message ID and event.
-func (r *redisStream) ConsumeMessages(groupName, consumerName, startID string, count int64, block int64, streams ...string) (string, event.Event, error) {
+// The returned context is derived from ctx and carries the trace context and request ID of the producer.
+func (r *redisStream) ConsumeMessages(ctx context.Context, groupName, consumerName, startID string, count int64, block int64, streams ...string) (context.Context, string, event.Event, error) {
 	if len(streams) == 0 {
-		return "", event.Event{}, fmt.Errorf("no streams specified")
+		return ctx, "", event.Event{}, fmt.Errorf("no streams specified")
 	}
 
 	streamIDs := make([]string, 0, len(streams)*2)
@@ -178,14 +215,14 @@ func (r *redisStream) ConsumeMessages(groupName, consumerName, startID string, c
 	}
 
 	builder := r.client.B().Xreadgroup().Group(groupName, GenerateConsumerName(consumerName)).Block(block).Streams().Key(streams...).Id(streamIDs...)
-	resp := r.client.Do(r.ctx, builder.Build())
+	resp := r.client.Do(ctx, builder.Build())
 	if resp.Error() != nil {
-		return "", event.Event{}, fmt.Errorf("failed to read messages from stream: %w", resp.Error())
+		return ctx, "", event.Event{}, fmt.Errorf("failed to read messages from stream: %w", resp.Error())
 	}
 
 	xEntry, err := resp.AsXRead()
 	if err != nil {
-		return "", event.Event{}, fmt.Errorf("failed to read messages from stream: response is not a valid XRead")
+		return ctx, "", event.Event{}, fmt.Errorf("failed to read messages from stream: response is not a valid XRead")
 	}
 
 	for _, entries := range xEntry {
@@ -197,27 +234,34 @@ func (r *redisStream) ConsumeMessages(groupName, consumerName, startID string, c
 					continue
 				}
 
+				ctx = contextWithRequestID(ctx, &ev)
 				if config.GetConfig().Observability.Tracing.Enable {
 					if traceData, ok := fields.FieldValues["trace"]; ok {
 						carrier, err := utils.UnmarshalJSON[map[string]string](traceData)
 						if err == nil {
-							r.ctx = otel.GetTextMapPropagator().Extract(r.ctx, propagation.MapCarrier(carrier))
+							ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
 						} else {
 							log.Error("failed to unmarshal trace data", zap.Error(err))
 						}
 					}
 				}
 
-				return fields.ID, ev, nil // Return Redis message ID and event
+				return ctx, fields.ID, ev, nil // Return Redis message ID and event
 			}
 		}
 	}
 
-	return "", event.Event{}, fmt.Errorf("no data found in stream messages")
+	return ctx, "", event.Event{}, fmt.Errorf("no data found in stream messages")
 }
 
 // SubscribeDLQ subscribes to a dead letter queue (DLQ) stream for processing.
 func (r *redisStream) SubscribeDLQ(stream string, handler func(msg any, ctx context.Context) error) error {
+	_, err := r.SubscribeDLQHandle(stream, handler)
+	return err
+}
+
+// SubscribeDLQHandle implements Subscriber.
+func (r *redisStream) SubscribeDLQHandle(stream string, handler func(msg any, ctx context.Context) error) (Subscription, error) {
 	log.Info("Subscribing to Redis DLQ", zap.String("subject", stream))
 
 	dlqGroup := stream + "-dlq-group"
@@ -225,25 +269,58 @@ func (r *redisStream) SubscribeDLQ(stream string, handler func(msg any, ctx cont
 		log.Error("Error creating DLQ consumer group, it may already exist", zap.Error(err))
 	}
 
+	subCtx, sub := r.addSubscription(subscriptionKey{topic: stream, dlq: true})
 	go func() {
 		for {
-			msgId, ev, err := r.ConsumeMessages(dlqGroup, dlqGroup, ">", 1, 0, stream)
+			msgCtx, msgId, ev, err := r.ConsumeMessages(subCtx, dlqGroup, dlqGroup, ">", 1, 0, stream)
+			if subCtx.Err() != nil {
+				return // Unsubscribed or stream closed
+			}
 			if err != nil {
 				log.Error("Error consuming messages from DLQ stream", zap.Error(err))
 				time.Sleep(time.Second) // Wait before retrying
 				continue
 			}
 
-			if err := handler(ev.Data(), r.ctx); err != nil {
+			r.metrics.add(subCtx, opConsume, stream, dlqGroup)
+			ctx, span := startProcessSpan(msgCtx, systemRedis, stream, dlqGroup, &ev, 0)
+			err = handler(ev.Data(), ctx)
+			observability.EndSpan(span, err)
+			if err != nil {
 				log.Error("Failed to process DLQ event", zap.Error(err))
 				continue
 			}
 
 			r.ackMsg(stream, dlqGroup, msgId) // Acknowledge the message
+			r.metrics.add(subCtx, opAck, stream, dlqGroup)
 		}
 	}()
 
-	return nil
+	return sub, nil
+}
+
+// HealthCheck pings the Redis server.
+func (r *redisStream) HealthCheck(ctx context.Context) error {
+	return r.client.Do(ctx, r.client.B().Ping().Build()).Error()
+}
+
+// Unsubscribe stops the consumers running for the stream and group.
+func (r *redisStream) Unsubscribe(stream, group string) error {
+	found, err := r.subs.remove(subscriptionKey{topic: stream, group: group, dlq: group == ""})
+	if !found {
+		return fmt.Errorf("no subscription for stream %s and group %s", stream, group)
+	}
+	log.Info("Unsubscribing from stream", zap.String("stream", stream), zap.String("group", group))
+	return err
+}
+
+// addSubscription registers a consumer and returns the context that stops it when cancelled.
+func (r *redisStream) addSubscription(key subscriptionKey) (context.Context, Subscription) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	return ctx, r.subs.add(key, func() error {
+		cancel()
+		return nil
+	})
 }
 
 // ackMsg acknowledges the processing of a message in the specified stream and group.
@@ -255,8 +332,16 @@ func (r *redisStream) ackMsg(stream, group, messageID string) {
 	}
 }
 
-// PublishDLQ sends an event to the dead letter queue (DLQ) stream.
-func (r *redisStream) PublishDLQ(ev event.Event, dlqStream string) {
-	data, _ := ev.MarshalJSON()
-	r.client.B().Xadd().Key(dlqStream).Id("*").FieldValue().FieldValue("event", rueidis.BinaryString(data)).Build()
+// PublishDLQ sends an event to the dead letter queue (DLQ) stream. The XADD command must be
+// executed, not only built, for the event to reach the stream.
+func (r *redisStream) PublishDLQ(ev event.Event, dlqStream string) error {
+	data, err := ev.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	builder := r.client.B().Xadd().Key(dlqStream).Id("*").FieldValue().FieldValue("event", rueidis.BinaryString(data))
+	if err := r.client.Do(r.ctx, builder.Build()).Error(); err != nil {
+		return fmt.Errorf("failed to add message to DLQ stream %s: %w", dlqStream, err)
+	}
+	return nil
 }
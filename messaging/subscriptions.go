@@ -0,0 +1,110 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// Subscription is a running subscription.
+type Subscription interface {
+	// Unsubscribe stops the subscription. Calling it again has no effect.
+	Unsubscribe() error
+}
+
+// Subscriber is implemented by streams returning a handle for each subscription, so that
+// subscriptions sharing a topic and group, such as those of different modules, can be
+// stopped individually.
+type Subscriber interface {
+	SubscribeHandle(topic, group string, handler func(msg *event.Event, ctx context.Context) error) (Subscription, error)
+	SubscribeDLQHandle(topic string, handler func(msg any, ctx context.Context) error) (Subscription, error)
+}
+
+// subscriptionKey identifies the subscriptions to a topic by group. DLQ subscriptions
+// have their own keys.
+type subscriptionKey struct {
+	topic string
+	group string
+	dlq   bool
+}
+
+// subscriptions keeps track of the running subscriptions of a stream.
+type subscriptions struct {
+	mu   sync.Mutex
+	next uint64
+	subs map[subscriptionKey]map[uint64]func() error
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{subs: make(map[subscriptionKey]map[uint64]func() error)}
+}
+
+// add records a subscription stopped by stop and returns its handle.
+func (s *subscriptions) add(key subscriptionKey, stop func() error) Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	if s.subs[key] == nil {
+		s.subs[key] = make(map[uint64]func() error)
+	}
+	s.subs[key][s.next] = stop
+	return &subscriptionHandle{subs: s, key: key, id: s.next}
+}
+
+// remove stops all the subscriptions of the key and reports whether there were any.
+func (s *subscriptions) remove(key subscriptionKey) (bool, error) {
+	s.mu.Lock()
+	stops := s.subs[key]
+	delete(s.subs, key)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(stops) > 0, errors.Join(errs...)
+}
+
+// removeAll stops all the subscriptions.
+func (s *subscriptions) removeAll() error {
+	s.mu.Lock()
+	all := s.subs
+	s.subs = make(map[subscriptionKey]map[uint64]func() error)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, stops := range all {
+		for _, stop := range stops {
+			if err := stop(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// subscriptionHandle is the Subscription returned by subscriptions.add.
+type subscriptionHandle struct {
+	subs *subscriptions
+	key  subscriptionKey
+	id   uint64
+}
+
+// Unsubscribe implements Subscription.
+func (h *subscriptionHandle) Unsubscribe() error {
+	h.subs.mu.Lock()
+	stop, ok := h.subs.subs[h.key][h.id]
+	delete(h.subs.subs[h.key], h.id)
+	if len(h.subs.subs[h.key]) == 0 {
+		delete(h.subs.subs, h.key)
+	}
+	h.subs.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return stop()
+}
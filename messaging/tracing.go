@@ -0,0 +1,53 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/trinitytechnology/ebrick/observability"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = observability.InternalTracer("messaging")
+
+// startPublishSpan starts the producer span of an event published to topic.
+// The context of the span is the one to propagate in the message headers.
+func startPublishSpan(ctx context.Context, system, topic string, ev *event.Event) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(system),
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingOperationName(opPublish),
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingMessageID(ev.ID()),
+		),
+	)
+}
+
+// startProcessSpan starts the consumer span handling an event received on topic. ctx carries
+// the producer span extracted from the message headers, the span is its child and links to it.
+// retries is the number of previous failed deliveries of the event.
+func startProcessSpan(ctx context.Context, system, topic, group string, ev *event.Event, retries int) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String(system),
+		semconv.MessagingOperationTypeDeliver,
+		semconv.MessagingOperationName("process"),
+		semconv.MessagingDestinationName(topic),
+		attribute.String("messaging.consumer.group.name", group),
+		attribute.Int("messaging.message.retry_count", retries),
+	}
+	if ev != nil {
+		attrs = append(attrs, semconv.MessagingMessageID(ev.ID()))
+	}
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(attrs...)}
+	if producer := trace.SpanContextFromContext(ctx); producer.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: producer}))
+	}
+	return tracer.Start(ctx, "process "+topic, opts...)
+}
@@ -0,0 +1,89 @@
+package module
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/web/middleware"
+	"github.com/trinitytechnology/ebrick/web/problem"
+)
+
+// The errors of the module manager are rendered by the administration endpoints as problems
+// with these status codes; other errors are internal server errors.
+func init() {
+	for _, err := range []error{ErrModuleNotFound, ErrManifestNotFound} {
+		problem.Register(err, http.StatusNotFound)
+	}
+	for _, err := range []error{ErrInvalidManifest, ErrIncompatibleModule, ErrChecksumMismatch, ErrUntrustedModule} {
+		problem.Register(err, http.StatusUnprocessableEntity)
+	}
+	for _, err := range []error{
+		ErrInvalidModuleState, ErrModuleInUse, ErrModuleAlreadyRegistered, ErrDependencyNotFound,
+		ErrDependencyUnavailable, ErrDependencyVersion, ErrDependencyCycle, ErrRoutesFrozen,
+	} {
+		problem.Register(err, http.StatusConflict)
+	}
+}
+
+// RegisterAdminRoutes registers the module administration endpoints on the given router group.
+//
+//	GET  /modules              lists registered modules and their state
+//	GET  /modules/:id          returns the state of a module
+//	POST /modules/:id/enable   enables a disabled module or loads a new plugin
+//	POST /modules/:id/disable  disables a module
+//	POST /modules/reload       loads plugins added to MODULES_DIR
+//
+// The router group must authenticate callers with middleware.OIDCAuthMiddleware; requests
+// without an authenticated principal are rejected with 401.
+func (mm *ModuleManager) RegisterAdminRoutes(router gin.IRoutes) {
+	authenticated := middleware.RequireAuthenticated()
+	router.GET("/modules", authenticated, mm.listModulesHandler)
+	router.GET("/modules/:id", authenticated, mm.getModuleHandler)
+	router.POST("/modules/:id/enable", authenticated, mm.enableModuleHandler)
+	router.POST("/modules/:id/disable", authenticated, mm.disableModuleHandler)
+	router.POST("/modules/reload", authenticated, mm.reloadModulesHandler)
+}
+
+func (mm *ModuleManager) listModulesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, mm.GetModuleInfos())
+}
+
+func (mm *ModuleManager) getModuleHandler(c *gin.Context) {
+	info, err := mm.GetModuleInfo(c.Param("id"))
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (mm *ModuleManager) enableModuleHandler(c *gin.Context) {
+	id := c.Param("id")
+	if err := mm.EnableModule(c.Request.Context(), id); err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	mm.getModuleHandler(c)
+}
+
+func (mm *ModuleManager) disableModuleHandler(c *gin.Context) {
+	id := c.Param("id")
+	if err := mm.DisableModule(c.Request.Context(), id); err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	mm.getModuleHandler(c)
+}
+
+func (mm *ModuleManager) reloadModulesHandler(c *gin.Context) {
+	loaded, err := mm.ReloadDynamicModules(c.Request.Context())
+	if err != nil {
+		// The modules loaded before the failure are listed by GET /modules.
+		problem.AbortWithError(c, err)
+		return
+	}
+	if loaded == nil {
+		loaded = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"loaded": loaded})
+}
@@ -6,4 +6,32 @@ var (
 	ErrInvalidModuleType  = errors.New("invalid plugin type")
 	ErrModulePathNotFound = errors.New("Module path not found")
 	ErrModuleNotFound     = errors.New("Module not found")
+
+	ErrModuleAlreadyRegistered = errors.New("Module already registered")
+	ErrDependencyNotFound      = errors.New("Module dependency not found")
+	ErrDependencyVersion       = errors.New("Module dependency version not satisfied")
+	ErrDependencyCycle         = errors.New("Module dependency cycle detected")
+	ErrDependencyUnavailable   = errors.New("Module dependency not available")
+	ErrInvalidVersion          = errors.New("Invalid version")
+	ErrInvalidModuleState      = errors.New("Invalid module state")
+	ErrModuleInUse             = errors.New("Module is required by other modules")
+	ErrMiddlewareNotFound      = errors.New("Middleware not found")
+	ErrInvalidMiddleware       = errors.New("Invalid middleware configuration")
+	ErrRouteConflict           = errors.New("Route conflict")
+	ErrRoutesFrozen            = errors.New("Routes cannot be added while serving requests")
+	ErrModulePanic             = errors.New("Module panicked")
+	ErrInvalidSettings         = errors.New("Invalid module settings")
+	ErrSubscriptionNotFound    = errors.New("Subscription not found")
+
+	ErrManifestNotFound   = errors.New("Module manifest not found")
+	ErrInvalidManifest    = errors.New("Invalid module manifest")
+	ErrIncompatibleModule = errors.New("Module is not compatible with this ebrick version")
+	ErrChecksumMismatch   = errors.New("Module checksum mismatch")
+	ErrUntrustedModule    = errors.New("Module is not trusted")
+	ErrInvalidLoader      = errors.New("Invalid module loader")
+	ErrModuleNotRunning   = errors.New("Module process is not running")
+
+	ErrServiceNotFound          = errors.New("Service not found")
+	ErrServiceAlreadyRegistered = errors.New("Service already registered")
+	ErrInvalidService           = errors.New("Invalid service")
 )
@@ -0,0 +1,174 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/trinitytechnology/ebrick/messaging"
+)
+
+// subscription records a subscription made by a module so it can be detached and restored.
+type subscription struct {
+	topic      string
+	group      string
+	handler    func(ev *event.Event, ctx context.Context) error
+	dlqHandler func(msg any, ctx context.Context) error
+	// handle stops the subscription alone, when the stream implements messaging.Subscriber.
+	handle messaging.Subscription
+}
+
+// moduleEventStream wraps the shared event stream handed to a module and keeps
+// track of the module's subscriptions, so the manager can detach them when the
+// module is disabled and subscribe them again when it is enabled.
+type moduleEventStream struct {
+	messaging.CloudEventStream
+	mu       sync.Mutex
+	subs     []subscription
+	detached bool
+}
+
+func newModuleEventStream(es messaging.CloudEventStream) *moduleEventStream {
+	return &moduleEventStream{CloudEventStream: es}
+}
+
+// Subscribe implements messaging.CloudEventStream.
+func (s *moduleEventStream) Subscribe(topic, group string, handler func(ev *event.Event, ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := subscription{topic: topic, group: group, handler: handler}
+	if !s.detached {
+		if err := s.subscribe(&sub); err != nil {
+			return err
+		}
+	}
+	s.subs = append(s.subs, sub)
+	return nil
+}
+
+// SubscribeDLQ implements messaging.CloudEventStream.
+func (s *moduleEventStream) SubscribeDLQ(topic string, handler func(msg any, ctx context.Context) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub := subscription{topic: topic, dlqHandler: handler}
+	if !s.detached {
+		if err := s.subscribe(&sub); err != nil {
+			return err
+		}
+	}
+	s.subs = append(s.subs, sub)
+	return nil
+}
+
+// Unsubscribe implements messaging.CloudEventStream.
+// Only the subscriptions of the module are stopped; if it has none for the topic and group,
+// ErrSubscriptionNotFound is returned and the shared stream is left untouched.
+func (s *moduleEventStream) Unsubscribe(topic, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var kept []subscription
+	var errs []error
+	found := false
+	for _, sub := range s.subs {
+		if sub.topic != topic || sub.group != group {
+			kept = append(kept, sub)
+			continue
+		}
+		found = true
+		if !s.detached {
+			errs = append(errs, s.unsubscribe(&sub))
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s/%s", ErrSubscriptionNotFound, topic, group)
+	}
+	s.subs = kept
+	return errors.Join(errs...)
+}
+
+// subscribe subscribes to the stream, keeping the handle of the subscription if the stream returns one.
+func (s *moduleEventStream) subscribe(sub *subscription) error {
+	var err error
+	if subscriber, ok := s.CloudEventStream.(messaging.Subscriber); ok {
+		if sub.dlqHandler != nil {
+			sub.handle, err = subscriber.SubscribeDLQHandle(sub.topic, sub.dlqHandler)
+		} else {
+			sub.handle, err = subscriber.SubscribeHandle(sub.topic, sub.group, sub.handler)
+		}
+		return err
+	}
+	if sub.dlqHandler != nil {
+		return s.CloudEventStream.SubscribeDLQ(sub.topic, sub.dlqHandler)
+	}
+	return s.CloudEventStream.Subscribe(sub.topic, sub.group, sub.handler)
+}
+
+// unsubscribe stops the subscription, with its handle if it has one.
+func (s *moduleEventStream) unsubscribe(sub *subscription) error {
+	if sub.handle == nil {
+		return s.CloudEventStream.Unsubscribe(sub.topic, sub.group)
+	}
+	err := sub.handle.Unsubscribe()
+	sub.handle = nil
+	return err
+}
+
+// Close unsubscribes the module's subscriptions. The shared stream is closed by the application.
+func (s *moduleEventStream) Close() error {
+	err := s.detach()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = nil
+	return err
+}
+
+// Subscriptions returns the module's subscriptions as "topic" or "topic/group".
+func (s *moduleEventStream) Subscriptions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := make([]string, 0, len(s.subs))
+	for _, sub := range s.subs {
+		if sub.group == "" {
+			subs = append(subs, sub.topic)
+		} else {
+			subs = append(subs, sub.topic+"/"+sub.group)
+		}
+	}
+	return subs
+}
+
+// detach stops all subscriptions of the module while remembering them.
+func (s *moduleEventStream) detach() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.detached {
+		return nil
+	}
+	var errs []error
+	for i := range s.subs {
+		if err := s.unsubscribe(&s.subs[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.detached = true
+	return errors.Join(errs...)
+}
+
+// attach subscribes again to everything that was detached.
+func (s *moduleEventStream) attach() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.detached {
+		return nil
+	}
+	var errs []error
+	for i := range s.subs {
+		if err := s.subscribe(&s.subs[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.detached = false
+	return errors.Join(errs...)
+}
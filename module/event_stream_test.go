@@ -0,0 +1,73 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/trinitytechnology/ebrick/messaging"
+)
+
+// testStream is a shared stream counting its running subscriptions by topic and group.
+type testStream struct {
+	messaging.CloudEventStream
+	running      map[string]int
+	unsubscribed []string
+}
+
+type testSubscription struct {
+	stream *testStream
+	key    string
+}
+
+func (s *testSubscription) Unsubscribe() error {
+	s.stream.running[s.key]--
+	return nil
+}
+
+func (s *testStream) SubscribeHandle(topic, group string, handler func(msg *event.Event, ctx context.Context) error) (messaging.Subscription, error) {
+	s.running[topic+"/"+group]++
+	return &testSubscription{stream: s, key: topic + "/" + group}, nil
+}
+
+func (s *testStream) SubscribeDLQHandle(topic string, handler func(msg any, ctx context.Context) error) (messaging.Subscription, error) {
+	return s.SubscribeHandle(topic, "", nil)
+}
+
+func (s *testStream) Unsubscribe(topic, group string) error {
+	s.unsubscribed = append(s.unsubscribed, topic+"/"+group)
+	delete(s.running, topic+"/"+group)
+	return nil
+}
+
+func TestModuleEventStreamUnsubscribe(t *testing.T) {
+	shared := &testStream{running: make(map[string]int)}
+	billing, orders, audit := newModuleEventStream(shared), newModuleEventStream(shared), newModuleEventStream(shared)
+	handler := func(ev *event.Event, ctx context.Context) error { return nil }
+	for _, s := range []*moduleEventStream{billing, orders} {
+		if err := s.Subscribe("invoices", "workers", handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := billing.Unsubscribe("invoices", "workers"); err != nil {
+		t.Fatal(err)
+	}
+	if got := shared.running["invoices/workers"]; got != 1 {
+		t.Errorf("got %d running subscriptions, want the one of the other module", got)
+	}
+	if len(billing.Subscriptions()) != 0 || len(orders.Subscriptions()) != 1 {
+		t.Errorf("got subscriptions %v and %v", billing.Subscriptions(), orders.Subscriptions())
+	}
+
+	// A module without a subscription to the topic does not stop the others.
+	for _, s := range []*moduleEventStream{billing, audit} {
+		if err := s.Unsubscribe("invoices", "workers"); !errors.Is(err, ErrSubscriptionNotFound) {
+			t.Errorf("got %v, want %v", err, ErrSubscriptionNotFound)
+		}
+	}
+	if got := shared.running["invoices/workers"]; got != 1 || len(shared.unsubscribed) != 0 {
+		t.Errorf("got %d running subscriptions, shared stream unsubscribed %v", got, shared.unsubscribed)
+	}
+}
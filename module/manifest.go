@@ -0,0 +1,163 @@
+package module
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/utils"
+)
+
+// Manifest describes a dynamic module. It is shipped next to the plugin as MODULES_DIR/<id>.manifest.json
+// and validated before the plugin is opened. As it is as writable as the plugin, its checksum is
+// only trusted when it is signed with one of the configured module signing keys.
+type Manifest struct {
+	Id      string `json:"id"`
+	Version string `json:"version"`
+	// Ebrick is the version constraint on the ebrick framework the plugin was built against, see MatchVersion.
+	Ebrick       string       `json:"ebrick"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Checksum is the SHA-256 of the plugin file, as "sha256:<hex>" or "<hex>".
+	Checksum string `json:"checksum"`
+	// Signature is the base64 encoded Ed25519 signature of the manifest, see SigningPayload.
+	Signature string `json:"signature,omitempty"`
+}
+
+// manifestPath returns the manifest path of a dynamic module.
+func manifestPath(moduleId string) string {
+	return MODULES_DIR + "/" + moduleId + ".manifest.json"
+}
+
+// LoadManifest reads a module manifest from the given path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrManifestNotFound, path)
+		}
+		return nil, err
+	}
+	manifest, err := utils.UnmarshalJSONByte[Manifest](data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidManifest, path, err)
+	}
+	return &manifest, nil
+}
+
+// Validate checks that the manifest describes the module with the given id and that the module is
+// compatible with this version of ebrick.
+func (m *Manifest) Validate(moduleId string) error {
+	if m.Id != moduleId {
+		return fmt.Errorf("%w: manifest id %s does not match module %s", ErrInvalidManifest, m.Id, moduleId)
+	}
+	if _, err := parseVersion(m.Version); err != nil {
+		return fmt.Errorf("%w: module %s: %v", ErrInvalidManifest, moduleId, err)
+	}
+
+	compatible, err := MatchVersion(m.Ebrick, EbrickVersion)
+	if err != nil {
+		return fmt.Errorf("%w: module %s: %v", ErrInvalidManifest, moduleId, err)
+	}
+	if !compatible {
+		return fmt.Errorf("%w: module %s requires ebrick %s, running %s", ErrIncompatibleModule, moduleId, m.Ebrick, EbrickVersion)
+	}
+	return nil
+}
+
+// SigningPayload returns the bytes signed by Signature: the JSON encoding of the manifest without
+// its signature and with its checksum set to "sha256:<hex>" of sum, the SHA-256 of the plugin file.
+// The signature thus covers the id, version, requirements and dependencies of the plugin as well.
+func (m *Manifest) SigningPayload(sum string) ([]byte, error) {
+	signed := *m
+	signed.Checksum = "sha256:" + strings.ToLower(sum)
+	signed.Signature = ""
+	return json.Marshal(signed)
+}
+
+// Verify checks that sum, the hex encoded SHA-256 of the plugin file, is trusted: it must match the
+// checksum of the module configuration or, without one, the manifest must be signed with one of keys,
+// base64 encoded Ed25519 public keys. Invalid keys are skipped. A checksum in the manifest must
+// match sum as well.
+func (m *Manifest) Verify(cfg config.ModuleConfig, keys []string, sum string) error {
+	checksum := "sha256:" + sum
+	if !utils.IsBlank(&m.Checksum) && !sameChecksum(m.Checksum, sum) {
+		return fmt.Errorf("%w: module %s manifest expects %s, got %s", ErrChecksumMismatch, m.Id, m.Checksum, checksum)
+	}
+	if !utils.IsBlank(&cfg.Checksum) {
+		if !sameChecksum(cfg.Checksum, sum) {
+			return fmt.Errorf("%w: module %s expected %s, got %s", ErrChecksumMismatch, m.Id, cfg.Checksum, checksum)
+		}
+		return nil
+	}
+	if utils.IsBlank(&m.Signature) {
+		return fmt.Errorf("%w: module %s has no configured checksum and its manifest is not signed", ErrUntrustedModule, m.Id)
+	}
+	signature, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: module %s: invalid signature: %v", ErrInvalidManifest, m.Id, err)
+	}
+	payload, err := m.SigningPayload(sum)
+	if err != nil {
+		return err
+	}
+	var invalid []string
+	for _, key := range keys {
+		publicKey, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			invalid = append(invalid, key)
+			continue
+		}
+		if ed25519.Verify(publicKey, payload, signature) {
+			return nil
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%w: module %s is not signed with a trusted key, invalid signing keys %q were skipped", ErrUntrustedModule, m.Id, invalid)
+	}
+	return fmt.Errorf("%w: module %s is not signed with a trusted key", ErrUntrustedModule, m.Id)
+}
+
+// sameChecksum reports whether a checksum, as "sha256:<hex>" or "<hex>", is the hex encoded sum.
+func sameChecksum(checksum, sum string) bool {
+	return strings.EqualFold(strings.TrimPrefix(checksum, "sha256:"), sum)
+}
+
+// stageModule copies a plugin file to a new private directory and returns the path of the copy
+// with the hex encoded SHA-256 of the copied bytes. The copy is loaded instead of the original,
+// so the verified bytes are the loaded ones even if the original is replaced meanwhile.
+func stageModule(path string) (string, string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer src.Close()
+
+	dir, err := os.MkdirTemp("", "ebrick-module-")
+	if err != nil {
+		return "", "", err
+	}
+	staged := filepath.Join(dir, filepath.Base(path))
+	dst, err := os.OpenFile(staged, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o500)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(dst, h), src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	return staged, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,102 @@
+package module
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+func TestManifestVerify(t *testing.T) {
+	digest := sha256.Sum256([]byte("plugin"))
+	sum := hex.EncodeToString(digest[:])
+	other := hex.EncodeToString(make([]byte, sha256.Size))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := base64.StdEncoding.EncodeToString(publicKey)
+	// signed returns the manifest of the billing module, signed for the plugin with checksum sum.
+	signed := func(m Manifest, sum string) Manifest {
+		m.Id = "billing"
+		payload, err := m.SigningPayload(sum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload))
+		return m
+	}
+	edited := signed(Manifest{Version: "1.0.0", Ebrick: ">=0.1.0"}, sum)
+	edited.Version = "2.0.0"
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+		cfg      config.ModuleConfig
+		keys     []string
+		err      error
+	}{
+		{"configured checksum", Manifest{}, config.ModuleConfig{Checksum: "sha256:" + sum}, nil, nil},
+		{"configured hex checksum", Manifest{Checksum: sum}, config.ModuleConfig{Checksum: sum}, nil, nil},
+		{"configured checksum mismatch", Manifest{Checksum: other}, config.ModuleConfig{Checksum: other}, nil, ErrChecksumMismatch},
+		{"manifest checksum mismatch", Manifest{Checksum: other}, config.ModuleConfig{Checksum: sum}, nil, ErrChecksumMismatch},
+		{"manifest checksum only", Manifest{Checksum: sum}, config.ModuleConfig{}, []string{key}, ErrUntrustedModule},
+		{"signed", signed(Manifest{Checksum: sum, Version: "1.0.0"}, sum), config.ModuleConfig{}, []string{key}, nil},
+		{"signed without checksum", signed(Manifest{Version: "1.0.0"}, sum), config.ModuleConfig{}, []string{key}, nil},
+		{"signed other plugin", signed(Manifest{}, other), config.ModuleConfig{}, []string{key}, ErrUntrustedModule},
+		{"signed and edited", edited, config.ModuleConfig{}, []string{key}, ErrUntrustedModule},
+		{"signed with untrusted key", signed(Manifest{}, sum), config.ModuleConfig{},
+			[]string{base64.StdEncoding.EncodeToString(otherKey)}, ErrUntrustedModule},
+		{"invalid key before trusted key", signed(Manifest{}, sum), config.ModuleConfig{}, []string{"%", "c2hvcnQ=", key}, nil},
+		{"signed without trusted keys", signed(Manifest{}, sum), config.ModuleConfig{}, nil, ErrUntrustedModule},
+		{"malformed signature", Manifest{Signature: "%"}, config.ModuleConfig{}, []string{key}, ErrInvalidManifest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.manifest.Id = "billing"
+			if err := tt.manifest.Verify(tt.cfg, tt.keys, sum); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestStageModule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "billing.so")
+	if err := os.WriteFile(path, []byte("plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	staged, sum, err := stageModule(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Dir(staged))
+
+	// Replacing the original does not change the staged copy.
+	if err := os.WriteFile(path, []byte("replaced"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(data)
+	if string(data) != "plugin" || sum != hex.EncodeToString(digest[:]) {
+		t.Errorf("got %q with checksum %s", data, sum)
+	}
+	if info, err := os.Stat(filepath.Dir(staged)); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("staged directory is not private: %v %v", info.Mode(), err)
+	}
+}
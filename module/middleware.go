@@ -0,0 +1,71 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/web/middleware"
+)
+
+// MiddlewareFactory creates a middleware for the route group of a module. It returns an error
+// wrapping ErrInvalidMiddleware if the configuration of the module does not allow it.
+type MiddlewareFactory func(cfg config.ModuleConfig) (gin.HandlerFunc, error)
+
+// defaultMiddlewares returns the middlewares modules can enable by name in their configuration.
+func defaultMiddlewares() map[string]MiddlewareFactory {
+	return map[string]MiddlewareFactory{
+		"oidc": func(config.ModuleConfig) (gin.HandlerFunc, error) {
+			return middleware.OIDCAuthMiddleware(), nil
+		},
+		"tenant": func(config.ModuleConfig) (gin.HandlerFunc, error) {
+			return middleware.ResolveTenant(config.GetConfig().Tenant), nil
+		},
+		"ratelimit": func(cfg config.ModuleConfig) (gin.HandlerFunc, error) {
+			// Without a rate the bucket never refills and every request after the first is rejected.
+			if cfg.Route.RateLimit.Rate <= 0 {
+				return nil, fmt.Errorf("%w: ratelimit requires a positive route.ratelimit.rate, got %v",
+					ErrInvalidMiddleware, cfg.Route.RateLimit.Rate)
+			}
+			return middleware.RateLimit(cfg.Route.RateLimit.Rate, cfg.Route.RateLimit.Burst), nil
+		},
+		// Without roles or scopes any authenticated caller would be allowed.
+		"roles": func(cfg config.ModuleConfig) (gin.HandlerFunc, error) {
+			if len(cfg.Route.Roles) == 0 {
+				return nil, fmt.Errorf("%w: roles requires route.roles", ErrInvalidMiddleware)
+			}
+			return middleware.RequireRoles(cfg.Route.Roles...), nil
+		},
+		"scopes": func(cfg config.ModuleConfig) (gin.HandlerFunc, error) {
+			if len(cfg.Route.Scopes) == 0 {
+				return nil, fmt.Errorf("%w: scopes requires route.scopes", ErrInvalidMiddleware)
+			}
+			return middleware.RequireScopes(cfg.Route.Scopes...), nil
+		},
+	}
+}
+
+// RegisterMiddleware makes a middleware available to modules under the given name.
+// It must be called before modules are initialized.
+func (mm *ModuleManager) RegisterMiddleware(name string, factory MiddlewareFactory) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.middlewares[name] = factory
+}
+
+// moduleMiddlewares builds the middlewares configured for a module.
+func (mm *ModuleManager) moduleMiddlewares(cfg config.ModuleConfig) ([]gin.HandlerFunc, error) {
+	handlers := make([]gin.HandlerFunc, 0, len(cfg.Route.Middlewares))
+	for _, name := range cfg.Route.Middlewares {
+		factory, ok := mm.middlewares[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMiddlewareNotFound, name)
+		}
+		handler, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("module %s middleware %s: %w", cfg.Id, name, err)
+		}
+		handlers = append(handlers, handler)
+	}
+	return handlers, nil
+}
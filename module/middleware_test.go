@@ -0,0 +1,66 @@
+package module
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+func TestModuleMiddlewaresRateLimit(t *testing.T) {
+	mm := NewModuleManager()
+	tests := []struct {
+		name string
+		rate config.RateLimitConfig
+		err  error
+	}{
+		{"rate", config.RateLimitConfig{Rate: 10}, nil},
+		{"rate and burst", config.RateLimitConfig{Rate: 0.5, Burst: 5}, nil},
+		{"burst without rate", config.RateLimitConfig{Burst: 5}, ErrInvalidMiddleware},
+		{"zero rate", config.RateLimitConfig{}, ErrInvalidMiddleware},
+		{"negative rate", config.RateLimitConfig{Rate: -1}, ErrInvalidMiddleware},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.ModuleConfig{Id: "billing"}
+			cfg.Route.Middlewares = []string{"ratelimit"}
+			cfg.Route.RateLimit = tt.rate
+			handlers, err := mm.moduleMiddlewares(cfg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err == nil && len(handlers) != 1 {
+				t.Errorf("got %d handlers", len(handlers))
+			}
+		})
+	}
+}
+
+func TestModuleMiddlewaresNotFound(t *testing.T) {
+	cfg := config.ModuleConfig{Id: "billing"}
+	cfg.Route.Middlewares = []string{"unknown"}
+	if _, err := NewModuleManager().moduleMiddlewares(cfg); !errors.Is(err, ErrMiddlewareNotFound) {
+		t.Errorf("got %v, want %v", err, ErrMiddlewareNotFound)
+	}
+}
+
+func TestModuleMiddlewaresRequireRolesAndScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		route config.ModuleRouteConfig
+		err   error
+	}{
+		{"roles", config.ModuleRouteConfig{Middlewares: []string{"roles"}, Roles: []string{"billing"}}, nil},
+		{"scopes", config.ModuleRouteConfig{Middlewares: []string{"scopes"}, Scopes: []string{"invoices:read"}}, nil},
+		{"roles without roles", config.ModuleRouteConfig{Middlewares: []string{"roles"}, Scopes: []string{"invoices:read"}}, ErrInvalidMiddleware},
+		{"scopes without scopes", config.ModuleRouteConfig{Middlewares: []string{"scopes"}, Roles: []string{"billing"}}, ErrInvalidMiddleware},
+	}
+	mm := NewModuleManager()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := mm.moduleMiddlewares(config.ModuleConfig{Id: "billing", Route: tt.route}); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
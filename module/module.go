@@ -1,8 +1,33 @@
 package module
 
+import "context"
+
 // Module
 const (
 	MODULES_DIR = "modules"
+
+	// SourceStatic is the source of modules registered in code rather than loaded from a plugin.
+	SourceStatic = "static"
+
+	// LoaderPlugin loads a dynamic module as a Go plugin.
+	LoaderPlugin = "plugin"
+	// LoaderProcess runs a dynamic module in its own process, see package rpcplugin.
+	LoaderProcess = "process"
+)
+
+// EbrickVersion is the version of the framework, checked against the requirements of plugin manifests.
+// It can be set at build time with -ldflags "-X github.com/trinitytechnology/ebrick/module.EbrickVersion=x.y.z".
+var EbrickVersion = "0.1.0"
+
+// Status is the lifecycle status of a registered module.
+type Status string
+
+const (
+	StatusRegistered  Status = "registered"
+	StatusInitialized Status = "initialized"
+	StatusStarted     Status = "started"
+	StatusDisabled    Status = "disabled"
+	StatusFailed      Status = "failed"
 )
 
 type Initializer interface {
@@ -20,3 +45,41 @@ type Module interface {
 	Initializer
 	MetaDataProvider
 }
+
+// SettingsProvider is implemented by modules that have their own settings section in the configuration.
+// Settings returns a pointer to a struct holding the default settings; the manager decodes the
+// module's configured settings into it, validates it and passes it to Initialize as Options.Settings.
+type SettingsProvider interface {
+	Settings() any
+}
+
+// Dependency describes a module required by another module.
+// Version is an optional version constraint, see MatchVersion.
+type Dependency struct {
+	Id      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+// DependencyProvider is implemented by modules that depend on other modules.
+// The manager initializes dependencies before the modules that require them.
+type DependencyProvider interface {
+	Dependencies() []Dependency
+}
+
+// Starter is implemented by modules that run background work such as consumers or tickers.
+// Start is called after every module has been initialized successfully.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by modules that need to release resources on shutdown.
+// Started modules are stopped in reverse initialization order, so after the modules depending
+// on them; modules that failed or were never started are not stopped.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker is implemented by modules that can report their own health.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
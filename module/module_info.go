@@ -0,0 +1,73 @@
+package module
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ModuleInfo describes a registered module and its runtime state.
+type ModuleInfo struct {
+	Id            string   `json:"id"`
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	Description   string   `json:"description"`
+	Status        Status   `json:"status"`
+	Loader        string   `json:"loader"`
+	Source        string   `json:"source"`
+	InitDuration  string   `json:"init_duration"`
+	Prefix        string   `json:"prefix"`
+	Routes        []string `json:"routes"`
+	Subscriptions []string `json:"subscriptions"`
+	Dependencies  []string `json:"dependencies"`
+}
+
+// GetModuleInfo returns the runtime state of a registered module.
+func (mm *ModuleManager) GetModuleInfo(moduleId string) (ModuleInfo, error) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	entry, ok := mm.modules[moduleId]
+	if !ok {
+		return ModuleInfo{}, fmt.Errorf("%w: %s", ErrModuleNotFound, moduleId)
+	}
+	return entry.info(), nil
+}
+
+// GetModuleInfos returns the runtime state of all registered modules sorted by id.
+func (mm *ModuleManager) GetModuleInfos() []ModuleInfo {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	infos := make([]ModuleInfo, 0, len(mm.modules))
+	for _, entry := range mm.modules {
+		infos = append(infos, entry.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Id < infos[j].Id })
+	return infos
+}
+
+func (e *moduleEntry) info() ModuleInfo {
+	info := ModuleInfo{
+		Id:            e.module.Id(),
+		Name:          e.module.Name(),
+		Version:       e.module.Version(),
+		Description:   e.module.Description(),
+		Status:        e.status,
+		Loader:        e.loader,
+		Source:        e.source,
+		InitDuration:  e.initTime.String(),
+		Prefix:        e.prefix,
+		Routes:        e.routes,
+		Subscriptions: []string{},
+		Dependencies:  []string{},
+	}
+	if info.Routes == nil {
+		info.Routes = []string{}
+	}
+	if e.events != nil {
+		info.Subscriptions = e.events.Subscriptions()
+	}
+	for _, dep := range e.dependencies() {
+		info.Dependencies = append(info.Dependencies, strings.TrimSpace(dep.Id+" "+dep.Version))
+	}
+	return info
+}
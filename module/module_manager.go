@@ -1,38 +1,364 @@
 package module
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 	"plugin"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/health"
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/observability"
 	"github.com/trinitytechnology/ebrick/utils"
+	"github.com/trinitytechnology/ebrick/web/problem"
 	"go.uber.org/zap"
 )
 
+// moduleEntry holds a registered module together with its runtime state.
+type moduleEntry struct {
+	module   Module
+	status   Status
+	source   string
+	loader   string
+	prefix   string
+	routes   []string
+	events   *moduleEventStream
+	manifest *Manifest
+	initTime time.Duration
+}
+
 type ModuleManager struct {
 	options *Options
-	modules map[string]Module
+	mu      sync.Mutex
+	modules map[string]*moduleEntry
+	// pending holds the ids of registered modules awaiting initialization, in registration order.
+	pending []string
+	// order holds the ids of initialized modules, in initialization order.
+	order []string
+	// disabledRoutes holds the routes of disabled modules as "METHOD path".
+	disabledRoutes sync.Map
+	middlewares    map[string]MiddlewareFactory
+	// routesFrozen is set once the router serves requests, see FreezeRoutes.
+	routesFrozen bool
 }
 
 func NewModuleManager(options ...Option) *ModuleManager {
+	opts := newOptions(options...)
+	if opts.Services == nil {
+		opts.Services = NewServiceRegistry()
+	}
+	if opts.Health == nil {
+		opts.Health = health.NewRegistry()
+	}
 
-	return &ModuleManager{
-		modules: make(map[string]Module),
-		options: newOptions(options...),
+	mm := &ModuleManager{
+		modules:     make(map[string]*moduleEntry),
+		options:     opts,
+		middlewares: defaultMiddlewares(),
 	}
+
+	// Gin cannot remove routes, so routes of disabled modules are rejected by this middleware.
+	// It must be installed before modules register their routes.
+	if opts.engine != nil {
+		opts.engine.Use(mm.routeGate())
+	}
+	return mm
+}
+
+// Services returns the registry modules use to expose and consume services.
+func (mm *ModuleManager) Services() *ServiceRegistry {
+	return mm.options.Services
 }
 
+// RegisterModule registers a module to be initialized by InitializeModules.
 func (mm *ModuleManager) RegisterModule(m Module) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.register(m, SourceStatic, nil)
+}
+
+func (mm *ModuleManager) register(m Module, source string, manifest *Manifest) error {
 	log := mm.options.Logger
-	log.Info("Registering module", zap.String("id", m.Id()))
-	if err := m.Initialize(mm.options); err != nil {
-		log.Error("Initialize module error", zap.Error(err))
-		return err
+	log.Info("Registering module", zap.String("id", m.Id()), zap.String("source", source))
+	if _, ok := mm.modules[m.Id()]; ok {
+		return fmt.Errorf("%w: %s", ErrModuleAlreadyRegistered, m.Id())
+	}
+	loader := LoaderPlugin
+	if source == SourceStatic {
+		loader = SourceStatic
+	} else if _, ok := m.(*processModule); ok {
+		loader = LoaderProcess
 	}
-	mm.modules[m.Id()] = m
+	mm.modules[m.Id()] = &moduleEntry{
+		module:   m,
+		status:   StatusRegistered,
+		source:   source,
+		loader:   loader,
+		manifest: manifest,
+	}
+	mm.pending = append(mm.pending, m.Id())
 	log.Info("Module registered", zap.String("id", m.Id()), zap.String("name", m.Name()), zap.String("version", m.Version()))
 	return nil
 }
 
+// InitializeModules initializes all pending modules in dependency order.
+// It fails without initializing any module when a dependency is missing,
+// does not satisfy the required version or forms a cycle.
+func (mm *ModuleManager) InitializeModules() error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.initializeModules()
+}
+
+func (mm *ModuleManager) initializeModules() error {
+	log := mm.options.Logger
+	sorted, err := mm.resolveDependencies()
+	if err != nil {
+		log.Error("Resolve module dependencies error", zap.Error(err))
+		return err
+	}
+
+	for i, id := range sorted {
+		entry := mm.modules[id]
+		log.Info("Initializing module", zap.String("id", id))
+
+		before, start := mm.routeSet(), time.Now()
+		err := mm.initializeModule(entry)
+		entry.initTime = time.Since(start)
+		entry.routes = mm.newRoutes(before)
+		if err != nil {
+			log.Error("Initialize module error", zap.String("id", id), zap.Error(err))
+			entry.status = StatusFailed
+			if pm, ok := entry.module.(*processModule); ok {
+				pm.kill()
+			}
+			if entry.events != nil {
+				if err := entry.events.detach(); err != nil {
+					log.Error("Detach module subscriptions error", zap.String("id", id), zap.Error(err))
+				}
+			}
+			mm.pending = sorted[i+1:]
+			return fmt.Errorf("initialize module %s: %w", id, err)
+		}
+		entry.status = StatusInitialized
+		mm.order = append(mm.order, id)
+		log.Info("Module initialized", zap.String("id", id), zap.String("prefix", entry.prefix), zap.Strings("routes", entry.routes))
+	}
+	mm.pending = nil
+	return nil
+}
+
+// initializeModule initializes a module with options scoped to it.
+// Gin panics when a route conflicts with a registered one; the panic is reported as
+// ErrRouteConflict. Other panics of the module are reported as ErrModulePanic.
+func (mm *ModuleManager) initializeModule(entry *moduleEntry) (err error) {
+	opts, err := mm.moduleOptions(entry)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if msg, ok := r.(string); ok && isRouteConflict(msg) {
+				if routes := mm.conflictingRoutes(msg); len(routes) > 0 {
+					msg += ", registered: " + strings.Join(routes, ", ")
+				}
+				err = fmt.Errorf("%w: %s", ErrRouteConflict, msg)
+				return
+			}
+			mm.options.Logger.Error("Module panicked during initialization", zap.String("id", entry.module.Id()),
+				zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrModulePanic, r)
+		}
+	}()
+	if err := entry.module.Initialize(opts); err != nil {
+		return err
+	}
+	// Once routes are frozen modules get a detached router, see moduleOptions.
+	if mm.routesFrozen && opts.engine != nil && len(opts.engine.Routes()) > 0 {
+		return fmt.Errorf("%w: module %s registers routes", ErrRoutesFrozen, entry.module.Id())
+	}
+	return nil
+}
+
+// FreezeRoutes prevents modules initialized from now on, when enabled or reloaded at runtime,
+// from registering routes, as gin does not support adding routes while serving requests.
+// Such modules fail to initialize with ErrRoutesFrozen. It is called before the HTTP server starts.
+func (mm *ModuleManager) FreezeRoutes() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.routesFrozen = true
+}
+
+// moduleOptions returns the options handed to a module, scoped to that module.
+func (mm *ModuleManager) moduleOptions(entry *moduleEntry) (*Options, error) {
+	opts := *mm.options
+	cfg := moduleConfig(entry.module.Id())
+
+	settings, err := decodeSettings(entry.module, cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts.Settings = settings
+	opts.Meter = observability.Meter(entry.module.Id())
+	if opts.Logger != nil {
+		opts.Logger = logger.Named(opts.Logger, entry.module.Id())
+	}
+
+	if opts.engine != nil {
+		if mm.routesFrozen {
+			// Routes registered on a detached engine are never served; they fail the initialization.
+			opts.engine = gin.New()
+		}
+		entry.prefix = utils.Default(&cfg.Route.Prefix, "/"+entry.module.Id())
+		handlers, err := mm.moduleMiddlewares(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.Router = opts.engine.Group(entry.prefix, handlers...)
+	}
+
+	if opts.EventStream != nil {
+		entry.events = newModuleEventStream(opts.EventStream)
+		opts.EventStream = entry.events
+	}
+	return &opts, nil
+}
+
+// moduleConfig returns the configuration of a module, or the defaults if it is not configured.
+func moduleConfig(moduleId string) config.ModuleConfig {
+	for _, cfg := range config.GetConfig().Modules {
+		if cfg.Id == moduleId {
+			return cfg
+		}
+	}
+	return config.ModuleConfig{Id: moduleId, Enable: true}
+}
+
+// isRouteConflict reports whether a panic message of gin is about a route conflicting with a
+// registered one.
+func isRouteConflict(msg string) bool {
+	return strings.Contains(msg, "handlers are already registered for path") ||
+		strings.Contains(msg, "conflicts with existing")
+}
+
+// conflictingRoutes returns the routes of initialized modules on the path of a route conflict
+// reported by gin, as "METHOD path (module)". Routes differing only by the names of their
+// wildcards are on the same path.
+func (mm *ModuleManager) conflictingRoutes(msg string) []string {
+	_, path, ok := strings.Cut(msg, "path '")
+	if !ok {
+		return nil
+	}
+	path, _, _ = strings.Cut(path, "'")
+	path = normalizePath(path)
+
+	var routes []string
+	for _, id := range mm.order {
+		for _, route := range mm.modules[id].routes {
+			if _, p, _ := strings.Cut(route, " "); normalizePath(p) == path {
+				routes = append(routes, route+" ("+id+")")
+			}
+		}
+	}
+	return routes
+}
+
+// normalizePath removes the names of the wildcards of a path.
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = ":"
+		} else if strings.HasPrefix(segment, "*") {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// resolveDependencies returns the pending module ids sorted so that every module comes after its dependencies.
+// Modules without ordering constraints keep their registration order.
+func (mm *ModuleManager) resolveDependencies() ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(mm.modules))
+	for _, id := range mm.order {
+		state[id] = visited
+	}
+	for id, entry := range mm.modules {
+		if entry.status == StatusFailed {
+			state[id] = visited
+		}
+	}
+
+	sorted := make([]string, 0, len(mm.pending))
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(path, id), " -> "))
+		}
+		state[id] = visiting
+
+		for _, dep := range mm.modules[id].dependencies() {
+			d, ok := mm.modules[dep.Id]
+			if !ok {
+				return fmt.Errorf("%w: module %s requires %s", ErrDependencyNotFound, id, dep.Id)
+			}
+			if d.status == StatusFailed || d.status == StatusDisabled {
+				return fmt.Errorf("%w: module %s requires %s which is %s", ErrDependencyUnavailable, id, dep.Id, d.status)
+			}
+			match, err := MatchVersion(dep.Version, d.module.Version())
+			if err != nil {
+				return fmt.Errorf("module %s dependency %s: %w", id, dep.Id, err)
+			}
+			if !match {
+				return fmt.Errorf("%w: module %s requires %s %s, found %s", ErrDependencyVersion, id, dep.Id, dep.Version, d.module.Version())
+			}
+			if err := visit(dep.Id, append(path, id)); err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+		sorted = append(sorted, id)
+		return nil
+	}
+
+	for _, id := range mm.pending {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// dependencies returns the dependencies declared by the module and by its manifest.
+func (e *moduleEntry) dependencies() []Dependency {
+	var deps []Dependency
+	if dp, ok := e.module.(DependencyProvider); ok {
+		deps = append(deps, dp.Dependencies()...)
+	}
+	if e.manifest != nil {
+		deps = append(deps, e.manifest.Dependencies...)
+	}
+	return deps
+}
+
 func (mm *ModuleManager) LoadDynamicModules() {
 	log := mm.options.Logger
 	log.Info("Loading dynamic modules")
@@ -53,20 +379,92 @@ func (mm *ModuleManager) LoadDynamicModules() {
 }
 
 func (mm *ModuleManager) RegisterModuleById(moduleId string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.registerModuleById(moduleId)
+}
+
+func (mm *ModuleManager) registerModuleById(moduleId string) error {
+	cfg := moduleConfig(moduleId)
 
 	// if module already installed
-	path := MODULES_DIR + "/" + moduleId + ".so"
+	var path string
+	switch cfg.Loader {
+	case "", LoaderPlugin:
+		path = MODULES_DIR + "/" + moduleId + ".so"
+	case LoaderProcess:
+		path = utils.Default(&cfg.Path, MODULES_DIR+"/"+moduleId)
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidLoader, cfg.Loader)
+	}
 
 	if !utils.FileExists(path) {
 		return ErrModuleNotFound
 	}
 
-	module, err := mm.LoadModule(path)
+	// Validate the manifest before opening the plugin, as opening an incompatible plugin may panic.
+	manifest, err := LoadManifest(manifestPath(moduleId))
 	if err != nil {
 		return err
 	}
+	if err := manifest.Validate(moduleId); err != nil {
+		return err
+	}
+
+	// The plugin is verified and loaded from a private copy, so it cannot be replaced in between.
+	staged, sum, err := stageModule(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(staged)
+	if err := manifest.Verify(cfg, config.GetConfig().ModuleSigningKeys, sum); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
 
-	return mm.RegisterModule(module)
+	var module Module
+	if cfg.Loader == LoaderProcess {
+		module, err = mm.LoadProcessModule(staged, cfg.Route.MaxBodySize)
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	} else {
+		module, err = mm.LoadModule(staged)
+		// The loaded plugin stays mapped once its file is removed.
+		os.RemoveAll(dir)
+	}
+	if err != nil {
+		return err
+	}
+	pm, _ := module.(*processModule)
+	if pm != nil {
+		pm.staged = dir
+	}
+	if module.Id() != manifest.Id || module.Version() != manifest.Version {
+		err = fmt.Errorf("%w: plugin is %s %s, manifest describes %s %s", ErrInvalidManifest, module.Id(), module.Version(), manifest.Id, manifest.Version)
+	} else {
+		err = mm.register(module, path, manifest)
+	}
+	if err != nil {
+		if pm != nil {
+			pm.kill()
+			pm.removeStaged()
+		}
+		return err
+	}
+	return nil
+}
+
+// LoadProcessModule runs the module executable in its own process and returns a module proxying to it.
+// Request and response bodies are limited to maxBodySize bytes, 10 MiB if it is not positive.
+func (mm *ModuleManager) LoadProcessModule(modulePath string, maxBodySize int64) (Module, error) {
+	log := mm.options.Logger
+	pm, err := launchProcessModule(modulePath, maxBodySize, log)
+	if err != nil {
+		log.Error("Failed to launch module", zap.String("path", modulePath), zap.Error(err))
+		return nil, err
+	}
+	return pm, nil
 }
 
 func (mm *ModuleManager) LoadModule(modulePath string) (Module, error) {
@@ -96,10 +494,291 @@ func (mm *ModuleManager) LoadModule(modulePath string) (Module, error) {
 	return loadedModule, nil
 }
 
+// ReloadDynamicModules loads, initializes and starts modules found in MODULES_DIR that are not registered yet.
+// Modules disabled in the configuration are skipped. It returns the ids of the loaded modules.
+// Modules registering routes once the HTTP server is started fail with ErrRoutesFrozen.
+func (mm *ModuleManager) ReloadDynamicModules(ctx context.Context) ([]string, error) {
+	log := mm.options.Logger
+	paths, err := filepath.Glob(filepath.Join(MODULES_DIR, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+
+	disabled := make(map[string]bool)
+	for _, m := range config.GetConfig().Modules {
+		if !m.Enable {
+			disabled[m.Id] = true
+		}
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	var loaded []string
+	var errs []error
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".so")
+		if _, ok := mm.modules[id]; ok || disabled[id] {
+			continue
+		}
+		log.Info("Found new dynamic module", zap.String("id", id), zap.String("path", path))
+		if err := mm.registerModuleById(id); err != nil {
+			errs = append(errs, fmt.Errorf("load module %s: %w", id, err))
+			continue
+		}
+		loaded = append(loaded, id)
+	}
+
+	if err := mm.initializeModules(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := mm.startModules(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return loaded, errors.Join(errs...)
+}
+
+// StartModules starts all initialized modules in initialization order.
+// Modules that implement Starter have their Start hook called.
+// Errors from individual modules are aggregated and returned together.
+func (mm *ModuleManager) StartModules(ctx context.Context) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return mm.startModules(ctx)
+}
+
+func (mm *ModuleManager) startModules(ctx context.Context) error {
+	var errs []error
+	for _, id := range mm.order {
+		entry := mm.modules[id]
+		if entry.status != StatusInitialized {
+			continue
+		}
+		if err := mm.startModule(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (mm *ModuleManager) startModule(ctx context.Context, entry *moduleEntry) error {
+	log := mm.options.Logger
+	id := entry.module.Id()
+	if s, ok := entry.module.(Starter); ok {
+		log.Info("Starting module", zap.String("id", id))
+		if err := s.Start(ctx); err != nil {
+			log.Error("Start module error", zap.String("id", id), zap.Error(err))
+			entry.status = StatusFailed
+			return fmt.Errorf("start module %s: %w", id, err)
+		}
+	}
+	if hc, ok := entry.module.(HealthChecker); ok {
+		mm.options.Health.Register(healthCheckName(id), hc.HealthCheck)
+	}
+	entry.status = StatusStarted
+	return nil
+}
+
+// healthCheckName returns the name of the health check of a module.
+func healthCheckName(moduleId string) string {
+	return "module:" + moduleId
+}
+
+// StopModules stops all started modules that implement Stopper in reverse initialization order.
+// The processes of process modules that were not started are killed.
+// Errors from individual modules are aggregated and returned together.
+func (mm *ModuleManager) StopModules(ctx context.Context) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	var errs []error
+	for i := len(mm.order) - 1; i >= 0; i-- {
+		entry := mm.modules[mm.order[i]]
+		if entry.status != StatusStarted {
+			continue
+		}
+		if err := mm.stopModule(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, entry := range mm.modules {
+		if pm, ok := entry.module.(*processModule); ok {
+			pm.shutdown()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (mm *ModuleManager) stopModule(ctx context.Context, entry *moduleEntry) error {
+	log := mm.options.Logger
+	id := entry.module.Id()
+	s, ok := entry.module.(Stopper)
+	if !ok {
+		return nil
+	}
+	log.Info("Stopping module", zap.String("id", id))
+	if err := s.Stop(ctx); err != nil {
+		log.Error("Stop module error", zap.String("id", id), zap.Error(err))
+		return fmt.Errorf("stop module %s: %w", id, err)
+	}
+	return nil
+}
+
+// EnableModule enables a module at runtime.
+// A disabled module gets its routes and event subscriptions back and is started again.
+// A module that is not registered yet is loaded from MODULES_DIR, initialized and started;
+// it fails with ErrRoutesFrozen if it registers routes once the HTTP server is started.
+func (mm *ModuleManager) EnableModule(ctx context.Context, moduleId string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	log := mm.options.Logger
+
+	entry, ok := mm.modules[moduleId]
+	if !ok {
+		if err := mm.registerModuleById(moduleId); err != nil {
+			return err
+		}
+		if err := mm.initializeModules(); err != nil {
+			return err
+		}
+		return mm.startModules(ctx)
+	}
+
+	if entry.status != StatusDisabled {
+		return fmt.Errorf("%w: %s is %s", ErrInvalidModuleState, moduleId, entry.status)
+	}
+	for _, dep := range entry.dependencies() {
+		if mm.modules[dep.Id].status == StatusDisabled {
+			return fmt.Errorf("%w: module %s requires %s which is %s", ErrDependencyUnavailable, moduleId, dep.Id, StatusDisabled)
+		}
+	}
+
+	log.Info("Enabling module", zap.String("id", moduleId))
+	if entry.events != nil {
+		if err := entry.events.attach(); err != nil {
+			return fmt.Errorf("attach subscriptions of module %s: %w", moduleId, err)
+		}
+	}
+	for _, route := range entry.routes {
+		mm.disabledRoutes.Delete(route)
+	}
+	return mm.startModule(ctx, entry)
+}
+
+// DisableModule disables a module at runtime.
+// The module is stopped, its event subscriptions are detached and its routes respond with 404.
+// Modules that other enabled modules depend on cannot be disabled.
+// Plugins cannot be unloaded from the process, so a disabled plugin stays in memory.
+func (mm *ModuleManager) DisableModule(ctx context.Context, moduleId string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	log := mm.options.Logger
+
+	entry, ok := mm.modules[moduleId]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, moduleId)
+	}
+	if entry.status == StatusDisabled || entry.status == StatusRegistered {
+		return fmt.Errorf("%w: %s is %s", ErrInvalidModuleState, moduleId, entry.status)
+	}
+	for id, other := range mm.modules {
+		if other.status == StatusDisabled || other.status == StatusRegistered {
+			continue
+		}
+		for _, dep := range other.dependencies() {
+			if dep.Id == moduleId {
+				return fmt.Errorf("%w: %s is required by %s", ErrModuleInUse, moduleId, id)
+			}
+		}
+	}
+
+	log.Info("Disabling module", zap.String("id", moduleId))
+	mm.options.Health.Unregister(healthCheckName(moduleId))
+	for _, route := range entry.routes {
+		mm.disabledRoutes.Store(route, moduleId)
+	}
+	var errs []error
+	if entry.status == StatusStarted {
+		if err := mm.stopModule(ctx, entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if entry.events != nil {
+		if err := entry.events.detach(); err != nil {
+			errs = append(errs, fmt.Errorf("detach subscriptions of module %s: %w", moduleId, err))
+		}
+	}
+	entry.status = StatusDisabled
+	return errors.Join(errs...)
+}
+
+// routeGate rejects requests to routes owned by disabled modules.
+func (mm *ModuleManager) routeGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, disabled := mm.disabledRoutes.Load(c.Request.Method + " " + c.FullPath()); disabled {
+			problem.Abort(c, http.StatusNotFound, "Module is disabled")
+			return
+		}
+		c.Next()
+	}
+}
+
+// routeSet returns the routes currently registered on the router as "METHOD path".
+func (mm *ModuleManager) routeSet() map[string]bool {
+	routes := make(map[string]bool)
+	if mm.options.engine == nil {
+		return routes
+	}
+	for _, r := range mm.options.engine.Routes() {
+		routes[r.Method+" "+r.Path] = true
+	}
+	return routes
+}
+
+// newRoutes returns the sorted routes registered since the given snapshot was taken.
+func (mm *ModuleManager) newRoutes(before map[string]bool) []string {
+	var routes []string
+	for route := range mm.routeSet() {
+		if !before[route] {
+			routes = append(routes, route)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// CheckHealth runs the health check of every enabled module that implements HealthChecker.
+// The result maps module ids to their health check error, nil meaning healthy.
+func (mm *ModuleManager) CheckHealth(ctx context.Context) map[string]error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	results := make(map[string]error)
+	for _, id := range mm.order {
+		entry := mm.modules[id]
+		if entry.status == StatusDisabled {
+			continue
+		}
+		if hc, ok := entry.module.(HealthChecker); ok {
+			results[id] = hc.HealthCheck(ctx)
+		}
+	}
+	return results
+}
+
 func (mm *ModuleManager) GetModule(moduleId string) Module {
-	return mm.modules[moduleId]
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	if entry, ok := mm.modules[moduleId]; ok {
+		return entry.module
+	}
+	return nil
 }
 
 func (mm *ModuleManager) GetModules() map[string]Module {
-	return mm.modules
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	modules := make(map[string]Module, len(mm.modules))
+	for id, entry := range mm.modules {
+		modules[id] = entry.module
+	}
+	return modules
 }
@@ -0,0 +1,178 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testModule is a static module recording the modules it is stopped with.
+type testModule struct {
+	id       string
+	version  string
+	deps     []Dependency
+	startErr error
+	stopped  *[]string
+}
+
+func (m *testModule) Id() string                 { return m.id }
+func (m *testModule) Name() string               { return m.id }
+func (m *testModule) Version() string            { return m.version }
+func (m *testModule) Description() string        { return "" }
+func (m *testModule) Dependencies() []Dependency { return m.deps }
+func (m *testModule) Initialize(*Options) error  { return nil }
+func (m *testModule) Start(context.Context) error {
+	return m.startErr
+}
+
+func (m *testModule) Stop(context.Context) error {
+	*m.stopped = append(*m.stopped, m.id)
+	return nil
+}
+
+func newTestModuleManager(t *testing.T, modules ...*testModule) *ModuleManager {
+	t.Helper()
+	mm := NewModuleManager(Logger(zap.NewNop()))
+	for _, m := range modules {
+		if m.version == "" {
+			m.version = "1.0.0"
+		}
+		if err := mm.RegisterModule(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mm
+}
+
+func TestStopModulesStopsStartedModulesInReverseOrder(t *testing.T) {
+	var stopped []string
+	mm := newTestModuleManager(t,
+		&testModule{id: "orders", deps: []Dependency{{Id: "billing"}}, stopped: &stopped},
+		&testModule{id: "audit", startErr: errors.New("broker is down"), stopped: &stopped},
+		&testModule{id: "billing", stopped: &stopped},
+	)
+	if err := mm.InitializeModules(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mm.StartModules(context.Background()); err == nil {
+		t.Fatal("got no error for the module failing to start")
+	}
+	if err := mm.StopModules(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"orders", "billing"}; !slices.Equal(stopped, want) {
+		t.Errorf("got %v stopped, want %v", stopped, want)
+	}
+}
+
+func TestStopModulesSkipsModulesNeverStarted(t *testing.T) {
+	var stopped []string
+	mm := newTestModuleManager(t, &testModule{id: "billing", stopped: &stopped})
+	if err := mm.InitializeModules(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mm.StopModules(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(stopped) != 0 {
+		t.Errorf("got %v stopped, want none", stopped)
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	dep := func(id, version string) []Dependency { return []Dependency{{Id: id, Version: version}} }
+	tests := []struct {
+		name    string
+		modules []*testModule
+		want    []string
+		err     error
+	}{
+		{"no dependencies keep registration order", []*testModule{{id: "c"}, {id: "a"}, {id: "b"}}, []string{"c", "a", "b"}, nil},
+		{"dependency first", []*testModule{{id: "orders", deps: dep("billing", "")}, {id: "billing"}}, []string{"billing", "orders"}, nil},
+		{"chain", []*testModule{
+			{id: "a", deps: dep("b", "")},
+			{id: "b", deps: dep("c", "")},
+			{id: "c"},
+		}, []string{"c", "b", "a"}, nil},
+		{"shared dependency", []*testModule{
+			{id: "a", deps: []Dependency{{Id: "c"}, {Id: "b"}}},
+			{id: "b", deps: dep("c", "")},
+			{id: "c"},
+			{id: "d"},
+		}, []string{"c", "b", "a", "d"}, nil},
+		{"missing dependency", []*testModule{{id: "orders", deps: dep("billing", "")}}, nil, ErrDependencyNotFound},
+		{"self dependency", []*testModule{{id: "a", deps: dep("a", "")}}, nil, ErrDependencyCycle},
+		{"cycle", []*testModule{
+			{id: "a", deps: dep("b", "")},
+			{id: "b", deps: dep("c", "")},
+			{id: "c", deps: dep("a", "")},
+		}, nil, ErrDependencyCycle},
+		{"version satisfied", []*testModule{{id: "orders", deps: dep("billing", ">=1.2.0, <2.0.0")}, {id: "billing", version: "1.4.2"}},
+			[]string{"billing", "orders"}, nil},
+		{"caret version satisfied", []*testModule{{id: "orders", deps: dep("billing", "^1.2")}, {id: "billing", version: "v1.9.0"}},
+			[]string{"billing", "orders"}, nil},
+		{"version too old", []*testModule{{id: "orders", deps: dep("billing", ">=1.2.0")}, {id: "billing", version: "1.1.9"}}, nil, ErrDependencyVersion},
+		{"major version differs", []*testModule{{id: "orders", deps: dep("billing", "^1.2")}, {id: "billing", version: "2.0.0"}}, nil, ErrDependencyVersion},
+		{"invalid constraint", []*testModule{{id: "orders", deps: dep("billing", ">=one")}, {id: "billing"}}, nil, ErrInvalidVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mm := newTestModuleManager(t, tt.modules...)
+			got, err := mm.resolveDependencies()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDependenciesOfInitializedModules(t *testing.T) {
+	mm := newTestModuleManager(t, &testModule{id: "billing"}, &testModule{id: "audit"})
+	if err := mm.InitializeModules(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mm.RegisterModule(&testModule{id: "orders", version: "1.0.0", deps: []Dependency{{Id: "billing"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := mm.resolveDependencies(); err != nil || !slices.Equal(got, []string{"orders"}) {
+		t.Errorf("got %v, %v; want only the pending module", got, err)
+	}
+
+	mm.modules["billing"].status = StatusDisabled
+	if _, err := mm.resolveDependencies(); !errors.Is(err, ErrDependencyUnavailable) {
+		t.Errorf("got %v, want %v", err, ErrDependencyUnavailable)
+	}
+}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+		err        error
+	}{
+		{"", "1.0.0", true, nil},
+		{"1.2.3", "1.2.3", true, nil},
+		{"=1.2.3", "1.2.4", false, nil},
+		{"!=1.2.3", "1.2.4", true, nil},
+		{">1.2.3", "1.2.3", false, nil},
+		{"<=1.2.3", "1.2.3-beta", true, nil},
+		{"~1.2.0", "1.2.9", true, nil},
+		{"~1.2.0", "1.3.0", false, nil},
+		{"^1.2.0", "1.1.0", false, nil},
+		{">=1.0.0, <2.0.0", "2.0.0", false, nil},
+		{">=1.0.0", "latest", false, ErrInvalidVersion},
+	}
+	for _, tt := range tests {
+		got, err := MatchVersion(tt.constraint, tt.version)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("MatchVersion(%q, %q) = %v, %v; want %v, %v", tt.constraint, tt.version, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -3,17 +3,34 @@ package module
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/trinitytechnology/ebrick/cache"
+	"github.com/trinitytechnology/ebrick/database"
+	"github.com/trinitytechnology/ebrick/health"
 	"github.com/trinitytechnology/ebrick/messaging"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type Options struct {
-	Database    *gorm.DB
-	Cache       cache.Cache
-	EventStream messaging.CloudEventStream
-	Logger      *zap.Logger
-	Router      *gin.Engine
+	Database *gorm.DB
+	// TenantDataSources resolves the connection of the tenant of a context, see
+	// repository.NewTenantRepositoryFromSources.
+	TenantDataSources *database.TenantDataSources
+	Cache             cache.Cache
+	EventStream       messaging.CloudEventStream
+	Logger            *zap.Logger
+	// Router is the route group of the module, see config.ModuleRouteConfig.
+	Router   *gin.RouterGroup
+	Services *ServiceRegistry
+	// Settings is the decoded settings struct of modules implementing SettingsProvider.
+	Settings any
+	// Health is the registry modules can add their own dependency checks to.
+	Health *health.Registry
+	// Meter is the meter modules register their own instruments with.
+	// Instruments are exported once metrics are enabled in the configuration.
+	Meter metric.Meter
+
+	engine *gin.Engine
 }
 
 type Option func(*Options)
@@ -35,6 +52,12 @@ func Database(db *gorm.DB) Option {
 	}
 }
 
+func TenantDataSources(s *database.TenantDataSources) Option {
+	return func(o *Options) {
+		o.TenantDataSources = s
+	}
+}
+
 func Cache(c cache.Cache) Option {
 	return func(o *Options) {
 		o.Cache = c
@@ -55,6 +78,19 @@ func Logger(l *zap.Logger) Option {
 
 func Router(r *gin.Engine) Option {
 	return func(o *Options) {
-		o.Router = r
+		o.engine = r
+		o.Router = &r.RouterGroup
+	}
+}
+
+func Services(r *ServiceRegistry) Option {
+	return func(o *Options) {
+		o.Services = r
+	}
+}
+
+func Health(r *health.Registry) Option {
+	return func(o *Options) {
+		o.Health = r
 	}
 }
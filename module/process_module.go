@@ -0,0 +1,448 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/module/rpcplugin"
+	"github.com/trinitytechnology/ebrick/web/problem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const (
+	// processHandshakeTimeout is how long a launched module has to start serving.
+	processHandshakeTimeout = 10 * time.Second
+	// defaultProcessMaxBodySize limits the request and response bodies proxied to a module process.
+	defaultProcessMaxBodySize = 10 << 20
+	// A process exiting unexpectedly is restarted up to maxProcessRestarts times, after a
+	// delay doubling from processRestartDelay. It is then unhealthy until enabled again. The
+	// count is reset when a process exits after running for processStableUptime.
+	maxProcessRestarts  = 5
+	processRestartDelay = time.Second
+	processStableUptime = time.Minute
+)
+
+// processModule is a module running in its own process. Its routes and event subscriptions
+// are proxied over the rpcplugin protocol. Unlike Go plugins, a process module is really
+// unloaded when it is stopped, and started again when it is enabled. A process exiting while
+// the module is started is restarted.
+type processModule struct {
+	path string
+	log  *zap.Logger
+	info *rpcplugin.InfoResponse
+	opts *Options
+	// maxBodySize limits the bodies of proxied requests and responses.
+	maxBodySize int64
+	// restartDelay is the delay before the first restart, see processRestartDelay.
+	restartDelay time.Duration
+	// lifecycle serializes the launches and stops of the process.
+	lifecycle sync.Mutex
+	mu        sync.RWMutex
+	stopped   bool
+	restarts  int
+	cmd       *exec.Cmd
+	exited    chan struct{}
+	client    *rpcplugin.Client
+	host      *grpc.Server
+	dir       string
+	// staged is the private directory of the verified copy of the executable, see stageModule.
+	staged string
+}
+
+// launchProcessModule runs the module executable and reads its description. Proxied request
+// and response bodies are limited to maxBodySize bytes, or defaultProcessMaxBodySize if it is not positive.
+func launchProcessModule(path string, maxBodySize int64, log *zap.Logger) (*processModule, error) {
+	if maxBodySize <= 0 {
+		maxBodySize = defaultProcessMaxBodySize
+	}
+	p := &processModule{path: path, log: log, maxBodySize: maxBodySize, restartDelay: processRestartDelay}
+	if err := p.launch(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// launch starts the module process and waits until it serves requests.
+func (p *processModule) launch() error {
+	dir, err := os.MkdirTemp("", "ebrick-module-")
+	if err != nil {
+		return err
+	}
+	pluginSocket := filepath.Join(dir, "plugin.sock")
+	hostSocket := filepath.Join(dir, "host.sock")
+
+	host, err := rpcplugin.ServeHost(hostSocket, p.publish)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	cmd := exec.Command(p.path)
+	cmd.Env = append(os.Environ(),
+		rpcplugin.EnvCookie+"="+rpcplugin.CookieValue,
+		rpcplugin.EnvPluginSocket+"="+pluginSocket,
+		rpcplugin.EnvHostSocket+"="+hostSocket,
+		rpcplugin.EnvMaxMessageSize+"="+strconv.Itoa(rpcplugin.MessageSize(p.maxBodySize)),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	p.log.Info("Launching module process", zap.String("path", p.path))
+	if err := cmd.Start(); err != nil {
+		host.Stop()
+		os.RemoveAll(dir)
+		return err
+	}
+	exited := make(chan struct{})
+	go p.watch(cmd, exited)
+
+	client, err := rpcplugin.Dial(pluginSocket, rpcplugin.MessageSize(p.maxBodySize))
+	if err == nil {
+		// The handshake fails as soon as the process exits.
+		ctx, cancel := context.WithTimeout(context.Background(), processHandshakeTimeout)
+		go func() {
+			select {
+			case <-exited:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		var info *rpcplugin.InfoResponse
+		info, err = client.Info(ctx)
+		cancel()
+		if err == nil && p.info != nil && (info.Id != p.info.Id || info.Version != p.info.Version) {
+			err = fmt.Errorf("%w: module changed from %s %s to %s %s", ErrInvalidModuleType, p.info.Id, p.info.Version, info.Id, info.Version)
+		}
+		if err == nil {
+			p.info = info
+		}
+	}
+
+	p.mu.Lock()
+	p.cmd, p.exited, p.client, p.host, p.dir = cmd, exited, client, host, dir
+	p.mu.Unlock()
+
+	if err != nil {
+		p.log.Error("Module process handshake failed", zap.String("path", p.path), zap.Error(err))
+		p.kill()
+		return err
+	}
+	return nil
+}
+
+// watch waits for the process to exit. A process exiting while it is running, rather than
+// being stopped or killed, is released and restarted.
+func (p *processModule) watch(cmd *exec.Cmd, exited chan struct{}) {
+	started := time.Now()
+	err := cmd.Wait()
+	close(exited)
+
+	p.mu.Lock()
+	crashed := p.cmd == cmd && !p.stopped
+	if crashed {
+		p.cmd = nil
+		p.release()
+		if time.Since(started) >= processStableUptime {
+			p.restarts = 0
+		}
+	}
+	p.mu.Unlock()
+
+	if !crashed {
+		p.log.Info("Module process exited", zap.String("path", p.path), zap.Error(err))
+		return
+	}
+	p.log.Error("Module process exited unexpectedly", zap.String("path", p.path), zap.Error(err))
+	p.restart()
+}
+
+// restart launches the process again after it exited, backing off between attempts.
+func (p *processModule) restart() {
+	for {
+		p.mu.Lock()
+		if p.restarts >= maxProcessRestarts {
+			p.mu.Unlock()
+			p.log.Error("Module process is not running, it is unhealthy until enabled again", zap.String("path", p.path))
+			return
+		}
+		p.restarts++
+		attempt, delay := p.restarts, p.restartDelay<<(p.restarts-1)
+		p.mu.Unlock()
+
+		time.Sleep(delay)
+		done, err := p.relaunch()
+		if done {
+			return
+		}
+		p.log.Warn("Failed to restart module process", zap.String("path", p.path), zap.Int("attempt", attempt), zap.Error(err))
+	}
+}
+
+// relaunch launches and initializes the process unless it was stopped or started meanwhile.
+// It reports whether no more attempt is needed.
+func (p *processModule) relaunch() (bool, error) {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	p.mu.RLock()
+	skip := p.stopped || p.client != nil
+	p.mu.RUnlock()
+	if skip {
+		return true, nil
+	}
+	err := p.launch()
+	if err == nil {
+		err = p.initializeProcess(context.Background())
+	}
+	if err != nil {
+		p.kill()
+		return false, err
+	}
+	p.log.Info("Module process restarted", zap.String("path", p.path))
+	return true, nil
+}
+
+// rpc returns the client of the running process.
+func (p *processModule) rpc() (*rpcplugin.Client, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotRunning, p.Id())
+	}
+	return p.client, nil
+}
+
+// kill terminates the process and releases its resources.
+func (p *processModule) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != nil {
+		p.cmd.Process.Kill()
+		<-p.exited
+		p.cmd = nil
+	}
+	p.release()
+}
+
+// release closes the connections to an exited process and removes its sockets. It must be
+// called with p.mu held.
+func (p *processModule) release() {
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+	if p.host != nil {
+		p.host.Stop()
+		p.host = nil
+	}
+	os.RemoveAll(p.dir)
+}
+
+// shutdown kills the process, if it is still running, without restarting it and removes the
+// verified copy of the executable.
+func (p *processModule) shutdown() {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.kill()
+	p.removeStaged()
+}
+
+// removeStaged removes the verified copy of the executable once the process is stopped for good.
+func (p *processModule) removeStaged() {
+	if p.staged != "" {
+		os.RemoveAll(p.staged)
+	}
+}
+
+// Id implements Module.
+func (p *processModule) Id() string {
+	return p.info.Id
+}
+
+// Name implements Module.
+func (p *processModule) Name() string {
+	return p.info.Name
+}
+
+// Version implements Module.
+func (p *processModule) Version() string {
+	return p.info.Version
+}
+
+// Description implements Module.
+func (p *processModule) Description() string {
+	return p.info.Description
+}
+
+// Initialize implements Module.
+// It initializes the module process and registers proxies for its routes and subscriptions.
+func (p *processModule) Initialize(options *Options) error {
+	p.opts = options
+	if err := p.initializeProcess(context.Background()); err != nil {
+		return err
+	}
+
+	for _, route := range p.info.Routes {
+		options.Router.Handle(route.Method, route.Path, p.proxyHTTP)
+	}
+	for _, sub := range p.info.Subscriptions {
+		if options.EventStream == nil {
+			return fmt.Errorf("module %s subscribes to %s but messaging is disabled", p.Id(), sub.Topic)
+		}
+		if err := options.EventStream.Subscribe(sub.Topic, sub.Group, p.eventHandler(sub)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *processModule) initializeProcess(ctx context.Context) error {
+	client, err := p.rpc()
+	if err != nil {
+		return err
+	}
+	return client.Initialize(ctx, moduleConfig(p.Id()).Settings)
+}
+
+// Start implements Starter. It launches the process again if it was stopped or exited.
+func (p *processModule) Start(ctx context.Context) error {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	p.mu.Lock()
+	p.stopped = false
+	p.restarts = 0
+	p.mu.Unlock()
+	if _, err := p.rpc(); err == nil {
+		return nil
+	}
+	if err := p.launch(); err != nil {
+		return err
+	}
+	return p.initializeProcess(ctx)
+}
+
+// Stop implements Stopper. It asks the process to exit and kills it when the context is done.
+func (p *processModule) Stop(ctx context.Context) error {
+	p.lifecycle.Lock()
+	defer p.lifecycle.Unlock()
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	client, err := p.rpc()
+	if err != nil {
+		return nil
+	}
+	err = client.Stop(ctx)
+	p.mu.RLock()
+	exited := p.exited
+	p.mu.RUnlock()
+	select {
+	case <-exited:
+	case <-ctx.Done():
+	}
+	p.kill()
+	return err
+}
+
+// HealthCheck implements HealthChecker.
+func (p *processModule) HealthCheck(ctx context.Context) error {
+	client, err := p.rpc()
+	if err != nil {
+		return err
+	}
+	return client.HealthCheck(ctx)
+}
+
+// proxyHTTP forwards a request to the module process.
+func (p *processModule) proxyHTTP(c *gin.Context) {
+	client, err := p.rpc()
+	if err != nil {
+		problem.Abort(c, http.StatusServiceUnavailable, "Module is not running")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, p.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			problem.Abort(c, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		problem.Abort(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	url := "/" + strings.TrimLeft(strings.TrimPrefix(c.Request.URL.Path, p.opts.Router.BasePath()), "/")
+	if c.Request.URL.RawQuery != "" {
+		url += "?" + c.Request.URL.RawQuery
+	}
+	req := &rpcplugin.HTTPRequest{
+		Method:     c.Request.Method,
+		URL:        url,
+		Header:     c.Request.Header,
+		Body:       body,
+		RemoteAddr: c.Request.RemoteAddr,
+		Metadata:   map[string]string{},
+	}
+	otel.GetTextMapPropagator().Inject(c.Request.Context(), propagation.MapCarrier(req.Metadata))
+
+	resp, err := client.HandleHTTP(c.Request.Context(), req)
+	if err != nil {
+		p.log.Error("Failed to proxy request to module", zap.String("id", p.Id()), zap.Error(err))
+		problem.Abort(c, http.StatusBadGateway, "Module request failed")
+		return
+	}
+	for k, v := range resp.Header {
+		c.Writer.Header()[k] = v
+	}
+	c.Status(resp.Status)
+	c.Writer.Write(resp.Body)
+}
+
+// eventHandler returns a handler forwarding events of the subscription to the module process.
+func (p *processModule) eventHandler(sub rpcplugin.Subscription) func(ev *event.Event, ctx context.Context) error {
+	return func(ev *event.Event, ctx context.Context) error {
+		client, err := p.rpc()
+		if err != nil {
+			return err
+		}
+		data, err := ev.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		req := &rpcplugin.EventRequest{Topic: sub.Topic, Group: sub.Group, Event: data, Metadata: map[string]string{}}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(req.Metadata))
+		return client.HandleEvent(ctx, req)
+	}
+}
+
+// publish publishes an event on behalf of the module process.
+func (p *processModule) publish(ctx context.Context, req *rpcplugin.EventRequest) error {
+	if p.opts == nil || p.opts.EventStream == nil {
+		return fmt.Errorf("module %s cannot publish to %s: messaging is disabled", p.Id(), req.Topic)
+	}
+	var ev event.Event
+	if err := ev.UnmarshalJSON(req.Event); err != nil {
+		return err
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(req.Metadata))
+	return p.opts.EventStream.Publish(req.Topic, ctx, ev)
+}
@@ -0,0 +1,174 @@
+package module
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/module/rpcplugin"
+	"go.uber.org/zap"
+)
+
+// envTestPluginLaunches is the file a test plugin appends a line to when it fails to start.
+const envTestPluginLaunches = "EBRICK_TEST_PLUGIN_LAUNCHES"
+
+// TestMain serves the test plugin when the test binary is launched as a module process.
+func TestMain(m *testing.M) {
+	if os.Getenv(rpcplugin.EnvCookie) == rpcplugin.CookieValue {
+		serveTestPlugin()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func serveTestPlugin() {
+	if path := os.Getenv(envTestPluginLaunches); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.WriteString("launch\n")
+			f.Close()
+		}
+		os.Exit(1)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	mux.HandleFunc("GET /crash", func(w http.ResponseWriter, r *http.Request) {
+		os.Exit(1)
+	})
+	err := rpcplugin.Serve(&rpcplugin.Plugin{
+		Id:      "echo",
+		Version: "1.0.0",
+		Routes:  []rpcplugin.Route{{Method: http.MethodPost, Path: "/echo"}, {Method: http.MethodGet, Path: "/crash"}},
+		Handler: mux,
+	})
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// launchTestPlugin runs the test plugin and registers its routes on the returned router.
+func launchTestPlugin(t *testing.T, maxBodySize int64) (*processModule, *gin.Engine) {
+	t.Helper()
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := launchProcessModule(path, maxBodySize, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		p.mu.Lock()
+		p.stopped = true
+		p.mu.Unlock()
+		p.kill()
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := p.Initialize(&Options{Router: router.Group("/echo")}); err != nil {
+		t.Fatal(err)
+	}
+	return p, router
+}
+
+func TestProcessModuleProxy(t *testing.T) {
+	_, router := launchTestPlugin(t, 8<<20)
+
+	// Bodies larger than the default message size of gRPC are proxied both ways.
+	body := bytes.Repeat([]byte("a"), 6<<20)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo/echo", bytes.NewReader(body)))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), body) {
+		t.Fatalf("got %d with %d bytes, want %d bytes", w.Code, w.Body.Len(), len(body))
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo/echo", bytes.NewReader(make([]byte, 8<<20+1))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcessModuleRestartsCrashedProcess(t *testing.T) {
+	p, router := launchTestPlugin(t, 0)
+	p.mu.Lock()
+	p.restartDelay = 10 * time.Millisecond
+	p.mu.Unlock()
+	p.mu.RLock()
+	crashed := p.cmd
+	p.mu.RUnlock()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo/crash", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusBadGateway)
+	}
+
+	waitFor(t, 10*time.Second, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.client != nil && p.cmd != crashed
+	})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo/echo", strings.NewReader("restarted")))
+	if w.Code != http.StatusOK || w.Body.String() != "restarted" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestProcessModuleGivesUpRestarting(t *testing.T) {
+	p, _ := launchTestPlugin(t, 0)
+	p.mu.Lock()
+	p.restartDelay = 10 * time.Millisecond
+	p.mu.Unlock()
+	launches := t.TempDir() + "/launches"
+	// Processes launched from now on exit at once.
+	t.Setenv(envTestPluginLaunches, launches)
+	count := func() int {
+		data, _ := os.ReadFile(launches)
+		return strings.Count(string(data), "\n")
+	}
+
+	start := time.Now()
+	p.mu.RLock()
+	p.cmd.Process.Kill()
+	p.mu.RUnlock()
+
+	waitFor(t, 10*time.Second, func() bool {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return count() == maxProcessRestarts && p.restarts == maxProcessRestarts && p.cmd == nil
+	})
+	// The delay doubles from restartDelay between attempts.
+	if elapsed, want := time.Since(start), p.restartDelay*(1<<maxProcessRestarts-1); elapsed < want {
+		t.Errorf("restarted %d times in %s, want at least %s", maxProcessRestarts, elapsed, want)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := count(); got != maxProcessRestarts {
+		t.Errorf("got %d launches, want %d", got, maxProcessRestarts)
+	}
+	if _, err := p.rpc(); !errors.Is(err, ErrModuleNotRunning) {
+		t.Errorf("got %v, want %v", err, ErrModuleNotRunning)
+	}
+}
@@ -0,0 +1,100 @@
+package rpcplugin
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client calls the Plugin service of a module running in its own process.
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the Plugin service listening on the Unix socket. Messages are limited to
+// maxMessageSize bytes, see MessageSize; the module must be launched with the same limit.
+func Dial(socket string, maxMessageSize int) (*Client, error) {
+	conn, err := dial(socket, grpc.MaxCallRecvMsgSize(maxMessageSize), grpc.MaxCallSendMsgSize(maxMessageSize))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+func dial(socket string, opts ...grpc.CallOption) (*grpc.ClientConn, error) {
+	return grpc.NewClient("unix://"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(append([]grpc.CallOption{grpc.ForceCodec(jsonCodec{})}, opts...)...),
+	)
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req, resp any, opts ...grpc.CallOption) error {
+	return c.conn.Invoke(ctx, "/"+pluginServiceName+"/"+method, req, resp, opts...)
+}
+
+// Info returns the description of the module. It waits until the module serves requests
+// or the context is done, so it is used as the handshake after launching a module.
+func (c *Client) Info(ctx context.Context) (*InfoResponse, error) {
+	resp := &InfoResponse{}
+	err := c.invoke(ctx, "Info", &Empty{}, resp, grpc.WaitForReady(true))
+	return resp, err
+}
+
+// Initialize initializes the module with its settings.
+func (c *Client) Initialize(ctx context.Context, settings map[string]any) error {
+	return c.invoke(ctx, "Initialize", &InitializeRequest{Settings: settings}, &Empty{})
+}
+
+// HandleHTTP forwards an HTTP request to the module.
+func (c *Client) HandleHTTP(ctx context.Context, req *HTTPRequest) (*HTTPResponse, error) {
+	resp := &HTTPResponse{}
+	err := c.invoke(ctx, "HandleHTTP", req, resp)
+	return resp, err
+}
+
+// HandleEvent forwards an event to the module.
+func (c *Client) HandleEvent(ctx context.Context, req *EventRequest) error {
+	return c.invoke(ctx, "HandleEvent", req, &Empty{})
+}
+
+// HealthCheck runs the health check of the module.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	return c.invoke(ctx, "HealthCheck", &Empty{}, &Empty{})
+}
+
+// Stop asks the module to release its resources and exit.
+func (c *Client) Stop(ctx context.Context) error {
+	return c.invoke(ctx, "Stop", &Empty{}, &Empty{})
+}
+
+// Close closes the connection to the module.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// PublishFunc publishes an event on behalf of a module.
+type PublishFunc func(ctx context.Context, req *EventRequest) error
+
+type host struct {
+	publish PublishFunc
+}
+
+func (h *host) Publish(ctx context.Context, req *EventRequest) (*Empty, error) {
+	return &Empty{}, h.publish(ctx, req)
+}
+
+// ServeHost serves the Host service on the Unix socket until the returned server is stopped.
+func ServeHost(socket string, publish PublishFunc) (*grpc.Server, error) {
+	os.Remove(socket)
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	srv := grpc.NewServer(grpc.ForceServerCodec(jsonCodec{}))
+	srv.RegisterService(&hostServiceDesc, &host{publish: publish})
+	go srv.Serve(lis)
+	return srv, nil
+}
@@ -0,0 +1,168 @@
+// Package rpcplugin implements the protocol between ebrick and modules running in their own process.
+//
+// The host launches the module binary with the environment variables below, the module serves the
+// Plugin service on a Unix socket and the host serves the Host service the module uses to publish
+// events. Both services are gRPC services using a JSON codec, so no code generation is needed.
+package rpcplugin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// EnvCookie holds CookieValue so a module binary can tell it was launched by ebrick.
+	EnvCookie = "EBRICK_PLUGIN_COOKIE"
+	// EnvPluginSocket holds the Unix socket path the module must serve the Plugin service on.
+	EnvPluginSocket = "EBRICK_PLUGIN_SOCKET"
+	// EnvHostSocket holds the Unix socket path of the Host service.
+	EnvHostSocket = "EBRICK_HOST_SOCKET"
+	// EnvMaxMessageSize holds the size in bytes of the largest message of the Plugin service,
+	// see MessageSize.
+	EnvMaxMessageSize = "EBRICK_PLUGIN_MAX_MESSAGE_SIZE"
+
+	CookieValue = "c2b7d3e0-ebrick-plugin-v1"
+
+	pluginServiceName = "ebrick.plugin.Plugin"
+	hostServiceName   = "ebrick.plugin.Host"
+
+	// messageOverhead is the room left in a message for the headers and metadata of a request.
+	messageOverhead = 1 << 20
+)
+
+// MessageSize returns the size of the largest message carrying an HTTP body of bodySize bytes.
+// Bodies are base64 encoded by the JSON codec.
+func MessageSize(bodySize int64) int {
+	return int(min(int64(base64.StdEncoding.EncodedLen(int(bodySize)))+messageOverhead, math.MaxInt32))
+}
+
+// Empty is the message of calls without arguments or results.
+type Empty struct{}
+
+// Route is an HTTP route served by a module, relative to the module's route prefix.
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Subscription is an event subscription of a module.
+type Subscription struct {
+	Topic string `json:"topic"`
+	Group string `json:"group"`
+}
+
+// InfoResponse describes a module.
+type InfoResponse struct {
+	Id            string         `json:"id"`
+	Name          string         `json:"name"`
+	Version       string         `json:"version"`
+	Description   string         `json:"description"`
+	Routes        []Route        `json:"routes"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// InitializeRequest carries the module settings from the configuration.
+type InitializeRequest struct {
+	Settings map[string]any `json:"settings"`
+}
+
+// HTTPRequest is an HTTP request proxied to a module. URL is relative to the module's route prefix.
+type HTTPRequest struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Header     map[string][]string `json:"header"`
+	Body       []byte              `json:"body"`
+	RemoteAddr string              `json:"remote_addr"`
+	Metadata   map[string]string   `json:"metadata"`
+}
+
+// HTTPResponse is the response of a module to a proxied HTTP request.
+type HTTPResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+// EventRequest is a CloudEvent delivered to or published by a module.
+// Event is the JSON encoded event and Metadata carries the trace context.
+type EventRequest struct {
+	Topic    string            `json:"topic"`
+	Group    string            `json:"group"`
+	Event    json.RawMessage   `json:"event"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// pluginServer is the Plugin service implemented by modules.
+type pluginServer interface {
+	Info(ctx context.Context, req *Empty) (*InfoResponse, error)
+	Initialize(ctx context.Context, req *InitializeRequest) (*Empty, error)
+	HandleHTTP(ctx context.Context, req *HTTPRequest) (*HTTPResponse, error)
+	HandleEvent(ctx context.Context, req *EventRequest) (*Empty, error)
+	HealthCheck(ctx context.Context, req *Empty) (*Empty, error)
+	Stop(ctx context.Context, req *Empty) (*Empty, error)
+}
+
+// hostServer is the Host service implemented by ebrick.
+type hostServer interface {
+	Publish(ctx context.Context, req *EventRequest) (*Empty, error)
+}
+
+var pluginServiceDesc = grpc.ServiceDesc{
+	ServiceName: pluginServiceName,
+	HandlerType: (*pluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unary(pluginServiceName, "Info", pluginServer.Info),
+		unary(pluginServiceName, "Initialize", pluginServer.Initialize),
+		unary(pluginServiceName, "HandleHTTP", pluginServer.HandleHTTP),
+		unary(pluginServiceName, "HandleEvent", pluginServer.HandleEvent),
+		unary(pluginServiceName, "HealthCheck", pluginServer.HealthCheck),
+		unary(pluginServiceName, "Stop", pluginServer.Stop),
+	},
+}
+
+var hostServiceDesc = grpc.ServiceDesc{
+	ServiceName: hostServiceName,
+	HandlerType: (*hostServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unary(hostServiceName, "Publish", hostServer.Publish),
+	},
+}
+
+// unary builds the method descriptor of a unary call handled by the given method of S.
+func unary[S, Req, Resp any](service, name string, call func(S, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(S), ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + service + "/" + name}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return call(srv.(S), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// jsonCodec encodes gRPC messages as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
@@ -0,0 +1,192 @@
+package rpcplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+)
+
+// ErrNotLaunchedByHost is returned by Serve when the binary is not launched by ebrick.
+var ErrNotLaunchedByHost = errors.New("module binary must be launched by ebrick")
+
+// EventHandler handles an event delivered to a module.
+type EventHandler func(ctx context.Context, ev *event.Event) error
+
+// Plugin is a module served out of process. Module binaries build a Plugin and call Serve from main.
+type Plugin struct {
+	Id          string
+	Name        string
+	Version     string
+	Description string
+
+	// Routes are served by Handler. Paths are relative to the module's route prefix
+	// and use the gin syntax, e.g. "/invoices/:id".
+	Routes  []Route
+	Handler http.Handler
+
+	// Subscriptions maps the topics and groups the module subscribes to to their handlers.
+	Subscriptions map[Subscription]EventHandler
+
+	// OnInitialize, OnStop and OnHealthCheck are optional lifecycle hooks.
+	OnInitialize  func(ctx context.Context, host *Host, settings map[string]any) error
+	OnStop        func(ctx context.Context) error
+	OnHealthCheck func(ctx context.Context) error
+}
+
+// Host lets a module publish events through the ebrick application that launched it.
+type Host struct {
+	conn *grpc.ClientConn
+}
+
+// Publish publishes an event to the topic through the host event stream.
+func (h *Host) Publish(ctx context.Context, topic string, ev event.Event) error {
+	data, err := ev.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	req := &EventRequest{Topic: topic, Event: data, Metadata: map[string]string{}}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(req.Metadata))
+	return h.conn.Invoke(ctx, "/"+hostServiceName+"/Publish", req, &Empty{})
+}
+
+// Serve serves the module until the host stops it.
+func Serve(p *Plugin) error {
+	if os.Getenv(EnvCookie) != CookieValue {
+		return ErrNotLaunchedByHost
+	}
+
+	hostConn, err := dial(os.Getenv(EnvHostSocket))
+	if err != nil {
+		return err
+	}
+	defer hostConn.Close()
+
+	socket := os.Getenv(EnvPluginSocket)
+	os.Remove(socket)
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+
+	opts := []grpc.ServerOption{grpc.ForceServerCodec(jsonCodec{})}
+	if size, err := strconv.Atoi(os.Getenv(EnvMaxMessageSize)); err == nil && size > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(size), grpc.MaxSendMsgSize(size))
+	}
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&pluginServiceDesc, &pluginService{
+		plugin: p,
+		host:   &Host{conn: hostConn},
+		stop:   func() { go srv.GracefulStop() },
+	})
+	return srv.Serve(lis)
+}
+
+// pluginService adapts a Plugin to the Plugin service.
+type pluginService struct {
+	plugin *Plugin
+	host   *Host
+	stop   func()
+}
+
+func (s *pluginService) Info(ctx context.Context, req *Empty) (*InfoResponse, error) {
+	subs := make([]Subscription, 0, len(s.plugin.Subscriptions))
+	for sub := range s.plugin.Subscriptions {
+		subs = append(subs, sub)
+	}
+	return &InfoResponse{
+		Id:            s.plugin.Id,
+		Name:          s.plugin.Name,
+		Version:       s.plugin.Version,
+		Description:   s.plugin.Description,
+		Routes:        s.plugin.Routes,
+		Subscriptions: subs,
+	}, nil
+}
+
+func (s *pluginService) Initialize(ctx context.Context, req *InitializeRequest) (*Empty, error) {
+	if s.plugin.OnInitialize != nil {
+		return &Empty{}, s.plugin.OnInitialize(ctx, s.host, req.Settings)
+	}
+	return &Empty{}, nil
+}
+
+func (s *pluginService) HandleHTTP(ctx context.Context, req *HTTPRequest) (*HTTPResponse, error) {
+	if s.plugin.Handler == nil {
+		return &HTTPResponse{Status: http.StatusNotFound}, nil
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(req.Metadata))
+	r, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header = req.Header
+	r.RemoteAddr = req.RemoteAddr
+
+	w := &responseWriter{header: http.Header{}}
+	s.plugin.Handler.ServeHTTP(w, r)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return &HTTPResponse{Status: w.status, Header: w.header, Body: w.body.Bytes()}, nil
+}
+
+func (s *pluginService) HandleEvent(ctx context.Context, req *EventRequest) (*Empty, error) {
+	handler, ok := s.plugin.Subscriptions[Subscription{Topic: req.Topic, Group: req.Group}]
+	if !ok {
+		return nil, errors.New("no handler for topic " + req.Topic)
+	}
+	var ev event.Event
+	if err := ev.UnmarshalJSON(req.Event); err != nil {
+		return nil, err
+	}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(req.Metadata))
+	return &Empty{}, handler(ctx, &ev)
+}
+
+func (s *pluginService) HealthCheck(ctx context.Context, req *Empty) (*Empty, error) {
+	if s.plugin.OnHealthCheck != nil {
+		return &Empty{}, s.plugin.OnHealthCheck(ctx)
+	}
+	return &Empty{}, nil
+}
+
+func (s *pluginService) Stop(ctx context.Context, req *Empty) (*Empty, error) {
+	defer s.stop()
+	if s.plugin.OnStop != nil {
+		return &Empty{}, s.plugin.OnStop(ctx)
+	}
+	return &Empty{}, nil
+}
+
+// responseWriter buffers the response of the module's HTTP handler.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
@@ -0,0 +1,113 @@
+package module
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ServiceRegistry holds services exposed by modules so other modules can consume them in-process.
+// Services are looked up by name; the generic helpers derive the name from the service type,
+// so a consumer only depends on the service interface and not on its implementation.
+// When a module is moved to another service, a remote proxy implementing the same
+// interface can be registered in its place without changing the consumers.
+type ServiceRegistry struct {
+	mu       sync.RWMutex
+	services map[string]any
+}
+
+// NewServiceRegistry creates an empty service registry.
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{
+		services: make(map[string]any),
+	}
+}
+
+// Register registers a service under the given name.
+func (r *ServiceRegistry) Register(name string, svc any) error {
+	if svc == nil {
+		return fmt.Errorf("%w: %s is nil", ErrInvalidService, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.services[name]; ok {
+		return fmt.Errorf("%w: %s", ErrServiceAlreadyRegistered, name)
+	}
+	r.services[name] = svc
+	return nil
+}
+
+// Unregister removes the service registered under the given name.
+func (r *ServiceRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.services, name)
+}
+
+// Lookup returns the service registered under the given name.
+func (r *ServiceRegistry) Lookup(name string) (any, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	svc, ok := r.services[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, name)
+	}
+	return svc, nil
+}
+
+// Names returns the sorted names of all registered services.
+func (r *ServiceRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.services))
+	for name := range r.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServiceName returns the name used to register services of type T,
+// e.g. "github.com/acme/tenant.TenantService".
+func ServiceName[T any]() string {
+	t := reflect.TypeFor[T]()
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// RegisterService registers a service under the name of type T.
+// T is usually the interface the service implements.
+func RegisterService[T any](r *ServiceRegistry, svc T) error {
+	return r.Register(ServiceName[T](), svc)
+}
+
+// GetService returns the service registered under the name of type T.
+func GetService[T any](r *ServiceRegistry) (T, error) {
+	return GetNamedService[T](r, ServiceName[T]())
+}
+
+// GetNamedService returns the service registered under the given name as type T.
+func GetNamedService[T any](r *ServiceRegistry, name string) (T, error) {
+	var zero T
+	svc, err := r.Lookup(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := svc.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T, not %s", ErrInvalidService, name, svc, reflect.TypeFor[T]())
+	}
+	return typed, nil
+}
+
+// MustGetService is like GetService but panics if the service is not available.
+func MustGetService[T any](r *ServiceRegistry) T {
+	svc, err := GetService[T](r)
+	if err != nil {
+		panic(err)
+	}
+	return svc
+}
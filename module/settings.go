@@ -0,0 +1,48 @@
+package module
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/trinitytechnology/ebrick/config"
+)
+
+// decodeSettings decodes the configured settings of a module into the struct it provides and validates it.
+// It returns nil if the module does not implement SettingsProvider.
+func decodeSettings(m Module, cfg config.ModuleConfig) (any, error) {
+	sp, ok := m.(SettingsProvider)
+	if !ok {
+		return nil, nil
+	}
+	settings := sp.Settings()
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           settings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if err := decoder.Decode(cfg.Settings); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+
+	if err := validator.New().Struct(settings); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	return settings, nil
+}
+
+// GetSettings returns the module settings passed in the options as type T.
+func GetSettings[T any](o *Options) (*T, error) {
+	settings, ok := o.Settings.(*T)
+	if !ok {
+		return nil, fmt.Errorf("%w: settings are %T, not *%T", ErrInvalidSettings, o.Settings, *new(T))
+	}
+	return settings, nil
+}
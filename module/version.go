@@ -0,0 +1,94 @@
+package module
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version. Pre-release and build metadata are ignored.
+type version [3]int
+
+// parseVersion parses versions such as "1.2.3", "v1.2" or "1.2.3-beta".
+func parseVersion(s string) (version, error) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return v, fmt.Errorf("%w: empty version", ErrInvalidVersion)
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v version) compare(o version) int {
+	for i := range v {
+		if v[i] != o[i] {
+			if v[i] < o[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// MatchVersion reports whether the version satisfies the constraint.
+// A constraint is a comma separated list of comparisons that must all hold,
+// e.g. ">=1.2.0, <2.0.0". Supported operators are =, !=, >, >=, <, <=,
+// ^ (same major version) and ~ (same minor version). An empty constraint matches any version.
+func MatchVersion(constraint, ver string) (bool, error) {
+	if strings.TrimSpace(constraint) == "" {
+		return true, nil
+	}
+	v, err := parseVersion(ver)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		op := c[:len(c)-len(strings.TrimLeft(c, "=!<>^~"))]
+		target, err := parseVersion(c[len(op):])
+		if err != nil {
+			return false, err
+		}
+		cmp := v.compare(target)
+		var ok bool
+		switch op {
+		case "", "=", "==":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "^":
+			ok = cmp >= 0 && v[0] == target[0]
+		case "~":
+			ok = cmp >= 0 && v[0] == target[0] && v[1] == target[1]
+		default:
+			return false, fmt.Errorf("%w: unknown operator %q", ErrInvalidVersion, op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -9,19 +9,21 @@ import (
 	"go.uber.org/zap"
 )
 
-// LoggerWithTraceID returns a logger with trace ID from the context.
+// LoggerWithTraceID returns a logger with the request ID and trace ID from the context.
 func LoggerWithTraceID(ctx context.Context) *zap.Logger {
-	if config.GetConfig().Observability.Tracing.Enable {
-		span := trace.SpanFromContext(ctx)
-		if span == nil {
-			return logger.DefaultLogger
-		}
-		sc := span.SpanContext()
-		return logger.DefaultLogger.With(
-			zap.String("trace_id", sc.TraceID().String()),
-			zap.String("span_id", sc.SpanID().String()),
-		)
-	} else {
-		return logger.DefaultLogger
+	log := logger.DefaultLogger
+	if id := RequestIDFromContext(ctx); id != "" {
+		log = log.With(zap.String("request_id", id))
 	}
+	if !config.GetConfig().Observability.Tracing.Enable {
+		return log
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return log
+	}
+	return log.With(
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	)
 }
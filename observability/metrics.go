@@ -0,0 +1,124 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+)
+
+const (
+	MetricsTypePrometheus = "prometheus"
+	MetricsTypeOTLP       = "otlp"
+
+	DefaultMetricsPath      = "/metrics"
+	defaultMetricsInterval  = 60 * time.Second
+	instrumentationName     = "github.com/trinitytechnology/ebrick"
+	moduleInstrumentationNs = instrumentationName + "/module/"
+)
+
+var DefaultMeterProvider, defaultMeterProviderErr = NewMeterProviderE()
+
+// NewMeterProvider creates a new instance of the meter provider.
+// It initializes the provider based on the configuration settings and returns it.
+// If metrics are disabled in the configuration, or cannot be initialized, it returns nil.
+func NewMeterProvider() *sdkmetric.MeterProvider {
+	mp, err := NewMeterProviderE()
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to initialize meter", zap.Error(err))
+	}
+	return mp
+}
+
+// NewMeterProviderE is NewMeterProvider returning the error of a metrics configuration that
+// cannot be applied. If metrics are disabled in the configuration, it returns nil and no error.
+func NewMeterProviderE() (*sdkmetric.MeterProvider, error) {
+	cfg := config.GetConfig().Observability.Metrics
+	if !cfg.Enable {
+		return nil, nil
+	}
+	mp, err := InitMeter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize meter: %w", err)
+	}
+	return mp, nil
+}
+
+// InitMeter creates the meter provider for the configured exporter and sets it as the global provider.
+// Instruments created from otel.Meter before the provider is set are bound to it once it is set.
+func InitMeter(cfg config.MetricsConfig) (*sdkmetric.MeterProvider, error) {
+	log := logger.DefaultLogger
+	log.Info("Initializing meter", zap.String("type", cfg.Type), zap.String("endpoint", cfg.Endpoint))
+
+	var reader sdkmetric.Reader
+	switch cfg.Type {
+	case "", MetricsTypePrometheus:
+		exporter, err := prometheus.New()
+		if err != nil {
+			return nil, err
+		}
+		reader = exporter
+	case MetricsTypeOTLP:
+		exporter, err := otlpmetricgrpc.New(context.Background(), otlpmetricgrpc.WithInsecure(), otlpmetricgrpc.WithEndpoint(cfg.Endpoint))
+		if err != nil {
+			return nil, err
+		}
+		interval := cfg.Interval
+		if interval <= 0 {
+			interval = defaultMetricsInterval
+		}
+		reader = sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(interval))
+	default:
+		return nil, fmt.Errorf("invalid metrics type: %s", cfg.Type)
+	}
+
+	res, err := NewResource(nil)
+	if err != nil {
+		return nil, err
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+	log.Info("Meter initialized")
+	return mp, nil
+}
+
+// MetricsHandler returns the handler serving the metrics in the Prometheus format.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// MetricsPath returns the path the Prometheus metrics are served on, or an empty string
+// if metrics are disabled or pushed over OTLP.
+func MetricsPath(cfg config.MetricsConfig) string {
+	if !cfg.Enable || (cfg.Type != "" && cfg.Type != MetricsTypePrometheus) {
+		return ""
+	}
+	if cfg.Endpoint == "" {
+		return DefaultMetricsPath
+	}
+	return cfg.Endpoint
+}
+
+// Meter returns a meter for instruments of the given module.
+// Modules use it to register their own counters, histograms and gauges.
+func Meter(moduleId string) metric.Meter {
+	return otel.Meter(moduleInstrumentationNs + moduleId)
+}
+
+// InternalMeter returns the meter used by the instruments of the given ebrick package.
+func InternalMeter(pkg string) metric.Meter {
+	return otel.Meter(instrumentationName + "/" + pkg)
+}
@@ -0,0 +1,45 @@
+package observability
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// MetricsMiddleware records the count, latency and in-flight number of HTTP requests per route.
+// Requests that match no route are recorded with an empty route to keep the cardinality bounded.
+func MetricsMiddleware() gin.HandlerFunc {
+	meter := InternalMeter("http")
+	duration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests."))
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to create HTTP metrics", zap.Error(err))
+	}
+	active, err := meter.Int64UpDownCounter("http.server.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of active HTTP server requests."))
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to create HTTP metrics", zap.Error(err))
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		method := attribute.String("http.request.method", c.Request.Method)
+		active.Add(ctx, 1, metric.WithAttributes(method))
+		start := time.Now()
+
+		c.Next()
+
+		active.Add(ctx, -1, metric.WithAttributes(method))
+		duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			method,
+			attribute.String("http.route", c.FullPath()),
+			attribute.Int("http.response.status_code", c.Writer.Status()),
+		))
+	}
+}
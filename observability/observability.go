@@ -1,27 +1,44 @@
 package observability
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/logger"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
-var DefaultTraceProvider *trace.TracerProvider = NewTracer()
+var DefaultTraceProvider, defaultTraceProviderErr = NewTracerE()
 
 // NewTracer creates a new instance of the tracer provider.
 // It initializes the tracer based on the configuration settings and returns the tracer provider.
-// If tracing is disabled in the configuration, it returns nil.
+// If tracing is disabled in the configuration, or cannot be initialized, it returns nil.
 func NewTracer() *trace.TracerProvider {
-	logger := zap.NewExample()
-	cfg := config.GetConfig().Observability.Tracing
+	tp, err := NewTracerE()
+	if err != nil {
+		logger.DefaultLogger.Error("Failed to initialize tracer", zap.Error(err))
+	}
+	return tp
+}
 
-	if cfg.Enable {
-		tp, err := InitTracer(cfg)
-		if err != nil {
-			logger.Error("Failed to initialize tracer", zap.Error(err))
-		}
-		return tp
+// NewTracerE is NewTracer returning the error of a tracing configuration that cannot be applied.
+// If tracing is disabled in the configuration, it returns nil and no error.
+func NewTracerE() (*trace.TracerProvider, error) {
+	cfg := config.GetConfig().Observability.Tracing
+	if !cfg.Enable {
+		return nil, nil
+	}
+	tp, err := InitTracer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracer: %w", err)
 	}
+	return tp, nil
+}
 
-	return nil
+// DefaultProvidersErr returns the errors creating DefaultTraceProvider and DefaultMeterProvider.
+// They are reported when the application is created rather than when the package is loaded.
+func DefaultProvidersErr() error {
+	return errors.Join(defaultTraceProviderErr, defaultMeterProviderErr)
 }
@@ -0,0 +1,52 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, RequestIDFromContext(c.Request.Context())) })
+
+	tests := []struct {
+		name, header string
+		kept         bool
+	}{
+		{"missing", "", false},
+		{"valid", "req-42_a.b", true},
+		{"max length", strings.Repeat("a", maxRequestIDLength), true},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"space", "req 42", false},
+		{"control", "req\x0142", false},
+		{"non ascii", "réq", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if w.Body.String() != id {
+				t.Errorf("got %q in the context, want %q", w.Body.String(), id)
+			}
+			if tt.kept && id != tt.header {
+				t.Errorf("got %q, want %q", id, tt.header)
+			}
+			if _, err := uuid.Parse(id); !tt.kept && err != nil {
+				t.Errorf("got %q, want a generated ID", id)
+			}
+		})
+	}
+}
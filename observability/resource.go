@@ -0,0 +1,74 @@
+package observability
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// instanceID identifies this process among the instances of the service.
+var instanceID = uuid.NewString()
+
+// NewResource creates the resource describing the service from the configuration,
+// with the given attributes added. Attributes from OTEL_RESOURCE_ATTRIBUTES are added too.
+func NewResource(attributes map[string]string) (*resource.Resource, error) {
+	cfg := config.GetConfig()
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(cfg.Service.Name),
+		semconv.ServiceInstanceID(instanceID),
+	}
+	if cfg.Service.Version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.Service.Version))
+	}
+	if cfg.Service.Project != "" {
+		attrs = append(attrs, semconv.ServiceNamespace(cfg.Service.Project))
+	}
+	if cfg.Env != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(cfg.Env))
+	}
+	for k, v := range attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return resource.New(context.Background(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+}
+
+// newTLSConfig creates the TLS configuration of an exporter, or returns nil if TLS is disabled.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
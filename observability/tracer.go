@@ -2,45 +2,132 @@ package observability
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/trinitytechnology/ebrick/config"
 	"github.com/trinitytechnology/ebrick/logger"
+	"go.opentelemetry.io/contrib/propagators/b3"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
 )
 
+const (
+	TracingTypeOTLPGRPC = "otlp-grpc"
+	TracingTypeOTLPHTTP = "otlp-http"
+	TracingTypeStdout   = "stdout"
+	TracingTypeNone     = "none"
+)
+
+// InitTracer creates the tracer provider for the configured exporter and sampler and sets it
+// as the global provider, together with the W3C and B3 propagators.
 func InitTracer(cfg config.TracingConfig) (*sdktrace.TracerProvider, error) {
 	logger := logger.DefaultLogger
-	logger.Info("Initializing tracer", zap.String("endpoint", cfg.Endpoint))
-	serviceName := config.GetConfig().Service.Name
-	ctx := context.Background()
-	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithInsecure(), otlptracegrpc.WithEndpoint(cfg.Endpoint))
+	logger.Info("Initializing tracer", zap.String("type", cfg.Type), zap.String("endpoint", cfg.Endpoint))
+
+	sampler, err := newSampler(cfg.SampleRatio)
+	if err != nil {
+		return nil, err
+	}
+	res, err := NewResource(cfg.Attributes)
 	if err != nil {
 		return nil, err
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serviceName),
-		)),
-	)
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sampler),
+	}
+	if cfg.Type != TracingTypeNone {
+		exporter, err := newTraceExporter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
 
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	// Accept and send both W3C trace context and B3 headers.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+		b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)),
+	))
 	otel.SetTracerProvider(tp)
 	logger.Info("Tracer initialized")
 	return tp, nil
 }
 
+// newTraceExporter creates the span exporter of the configured type.
+func newTraceExporter(cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	tlsCfg, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	switch cfg.Type {
+	case "", TracingTypeOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+		if strings.Contains(cfg.Endpoint, "://") {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		} else if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if tlsCfg != nil {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+
+	case TracingTypeOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		if strings.Contains(cfg.Endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		} else if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if tlsCfg != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+
+	case TracingTypeStdout:
+		return stdouttrace.New()
+
+	default:
+		return nil, fmt.Errorf("invalid tracing type: %s", cfg.Type)
+	}
+}
+
+// newSampler samples the given ratio of new traces, all of them if ratio is nil, and follows
+// the decision of the caller for continued traces.
+func newSampler(ratio *float64) (sdktrace.Sampler, error) {
+	switch {
+	case ratio == nil || *ratio == 1:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case *ratio == 0:
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case *ratio > 0 && *ratio < 1:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*ratio)), nil
+	default:
+		return nil, fmt.Errorf("invalid tracing sample ratio %v, must be between 0 and 1", *ratio)
+	}
+}
+
 func StartEventSpan(ctx context.Context, serviceName, spanName string, ev *event.Event) (context.Context, trace.Span) {
 	ctx, span := StartSpan(ctx, serviceName, spanName)
 	span.SetAttributes(
@@ -56,3 +143,17 @@ func StartSpan(ctx context.Context, serviceName, spanName string) (context.Conte
 	span.SetAttributes(attribute.String("module", serviceName))
 	return ctx, span
 }
+
+// InternalTracer returns the tracer used by the spans of the given ebrick package.
+func InternalTracer(pkg string) trace.Tracer {
+	return otel.Tracer(instrumentationName + "/" + pkg)
+}
+
+// EndSpan records err on the span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,49 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewSampler(t *testing.T) {
+	ratio := func(r float64) *float64 { return &r }
+	parent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	tests := []struct {
+		name        string
+		ratio       *float64
+		root, child bool
+	}{
+		{"default", nil, true, true},
+		{"all", ratio(1), true, true},
+		{"none", ratio(0), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler, err := newSampler(tt.ratio)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: trace.TraceID{2}})
+			child := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: parent, TraceID: trace.TraceID{1}})
+			if got := root.Decision == sdktrace.RecordAndSample; got != tt.root {
+				t.Errorf("got root sampled %v, want %v", got, tt.root)
+			}
+			if got := child.Decision == sdktrace.RecordAndSample; got != tt.child {
+				t.Errorf("got child sampled %v, want %v", got, tt.child)
+			}
+		})
+	}
+
+	for _, r := range []float64{-0.1, 1.5} {
+		if _, err := newSampler(ratio(r)); err == nil {
+			t.Errorf("got no error for ratio %v", r)
+		}
+	}
+}
@@ -1,25 +1,127 @@
 package observability
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trinitytechnology/ebrick/config"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-// TracingMiddleware adds tracing to the request context
+// TraceIDHeader is the response header carrying the trace ID of the request.
+const TraceIDHeader = "X-Trace-Id"
+
+// redactedQueryValue replaces the values of the query parameters that are not recorded.
+const redactedQueryValue = "REDACTED"
+
+// TracingMiddleware starts a server span for each request, continuing the trace of the
+// caller if the request carries W3C or B3 trace headers. The span is named after the route
+// template, so paths with IDs do not create a span name per ID, and responses with a 5xx
+// status mark it as failed. The trace ID is returned in the TraceIDHeader response header.
+// The query is only recorded for the parameters of the tracing configuration's QueryParams.
 func TracingMiddleware() gin.HandlerFunc {
+	tracer := InternalTracer("http")
+	queryParams := make(map[string]bool)
+	for _, name := range config.GetConfig().Observability.Tracing.QueryParams {
+		queryParams[name] = true
+	}
 	return func(c *gin.Context) {
-		serviceName := config.GetConfig().Service.Name
-		tracer := otel.Tracer(serviceName)
-		ctx, span := tracer.Start(c.Request.Context(), c.Request.URL.Path)
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		spanName := route
+		if spanName == "" {
+			spanName = "HTTP " + c.Request.Method
+		}
+		ctx, span := tracer.Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(requestAttributes(c, route, queryParams)...),
+		)
 		defer span.End()
 
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			c.Header(TraceIDHeader, sc.TraceID().String())
+		}
+
 		c.Request = c.Request.WithContext(ctx)
 		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if size := c.Writer.Size(); size > 0 {
+			span.SetAttributes(semconv.HTTPResponseBodySize(size))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// requestAttributes returns the HTTP semantic convention attributes of the request. The query
+// is recorded if it has any of queryParams, with the values of the other parameters redacted.
+func requestAttributes(c *gin.Context, route string, queryParams map[string]bool) []attribute.KeyValue {
+	req := c.Request
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLScheme(scheme),
+		semconv.URLPath(req.URL.Path),
+		semconv.ServerAddress(req.Host),
+		semconv.ClientAddress(c.ClientIP()),
+		semconv.NetworkProtocolVersion(fmt.Sprintf("%d.%d", req.ProtoMajor, req.ProtoMinor)),
+	}
+	if route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+	if query := recordedQuery(req.URL.RawQuery, queryParams); query != "" {
+		attrs = append(attrs, semconv.URLQuery(query))
+	}
+	if ua := req.UserAgent(); ua != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(ua))
+	}
+	if req.ContentLength > 0 {
+		attrs = append(attrs, semconv.HTTPRequestBodySize(int(req.ContentLength)))
+	}
+	return attrs
+}
+
+// recordedQuery returns the raw query with the values of the parameters not in params
+// redacted, or an empty string if it has none of params.
+func recordedQuery(rawQuery string, params map[string]bool) string {
+	if rawQuery == "" || len(params) == 0 {
+		return ""
+	}
+	pairs := strings.Split(rawQuery, "&")
+	recorded := false
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err == nil && params[name] {
+			recorded = true
+			continue
+		}
+		pairs[i] = key + "=" + redactedQueryValue
+	}
+	if !recorded {
+		return ""
 	}
+	return strings.Join(pairs, "&")
 }
 
 func LoggingWithTraceIDMiddleware() gin.HandlerFunc {
@@ -0,0 +1,141 @@
+package observability
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/config"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// newTracedRouter returns a router traced with the middleware, recording its spans.
+func newTracedRouter(t *testing.T, queryParams ...string) (*gin.Engine, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, b3.New()))
+	cfg := config.GetConfig()
+	params := cfg.Observability.Tracing.QueryParams
+	cfg.Observability.Tracing.QueryParams = queryParams
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+		cfg.Observability.Tracing.QueryParams = params
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TracingMiddleware())
+	router.GET("/orders/:id", func(c *gin.Context) { c.String(http.StatusOK, "order") })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	return router, recorder
+}
+
+// attr returns the value of the attribute key of the span.
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingMiddlewareSpanName(t *testing.T) {
+	router, recorder := newTracedRouter(t)
+	tests := []struct {
+		path, name string
+		status     codes.Code
+	}{
+		{"/orders/1", "/orders/:id", codes.Unset},
+		{"/orders/2", "/orders/:id", codes.Unset},
+		{"/fail", "/fail", codes.Error},
+		{"/missing", "HTTP GET", codes.Unset},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		if span.Name() != tt.name || span.Status().Code != tt.status {
+			t.Errorf("%s: got span %q with status %v, want %q with %v", tt.path, span.Name(), span.Status().Code, tt.name, tt.status)
+		}
+		if got := w.Header().Get(TraceIDHeader); got != span.SpanContext().TraceID().String() {
+			t.Errorf("%s: got trace ID header %q, want %s", tt.path, got, span.SpanContext().TraceID())
+		}
+		if status, _ := attr(span, semconv.HTTPResponseStatusCodeKey); status.AsInt64() != int64(w.Code) {
+			t.Errorf("%s: got status attribute %d, want %d", tt.path, status.AsInt64(), w.Code)
+		}
+		route, ok := attr(span, semconv.HTTPRouteKey)
+		if tt.name == "HTTP GET" && ok || tt.name != "HTTP GET" && route.AsString() != tt.name {
+			t.Errorf("%s: got route attribute %q", tt.path, route.AsString())
+		}
+	}
+}
+
+func TestTracingMiddlewarePropagation(t *testing.T) {
+	router, recorder := newTracedRouter(t)
+	tests := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{"w3c", map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}},
+		{"b3", map[string]string{
+			"X-B3-TraceId": "0af7651916cd43dd8448eb211c80319c",
+			"X-B3-SpanId":  "b7ad6b7169203331",
+			"X-B3-Sampled": "1",
+		}},
+		{"b3 single", map[string]string{"b3": "0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			span := spans[len(spans)-1]
+			if got := span.SpanContext().TraceID().String(); got != "0af7651916cd43dd8448eb211c80319c" {
+				t.Errorf("got trace ID %s", got)
+			}
+			if got := span.Parent().SpanID().String(); got != "b7ad6b7169203331" || !span.Parent().IsRemote() {
+				t.Errorf("got parent span %s", got)
+			}
+		})
+	}
+}
+
+func TestTracingMiddlewareQuery(t *testing.T) {
+	router, recorder := newTracedRouter(t, "page", "sort")
+	tests := []struct {
+		query, want string
+	}{
+		{"", ""},
+		{"page=2&sort=name", "page=2&sort=name"},
+		{"page=2&token=secret", "page=2&token=REDACTED"},
+		{"token=secret&email=a%40b.c", ""},
+		{"p%61ge=2&token", "p%61ge=2&token=REDACTED"},
+	}
+	for _, tt := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1?"+tt.query, nil))
+
+		spans := recorder.Ended()
+		query, ok := attr(spans[len(spans)-1], semconv.URLQueryKey)
+		if query.AsString() != tt.want || ok != (tt.want != "") {
+			t.Errorf("%q: got %q, want %q", tt.query, query.AsString(), tt.want)
+		}
+	}
+}
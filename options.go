@@ -1,49 +1,73 @@
 package ebrick
 
 import (
+	"time"
+
 	"github.com/trinitytechnology/ebrick/cache"
 	"github.com/trinitytechnology/ebrick/config"
 	"github.com/trinitytechnology/ebrick/database"
+	"github.com/trinitytechnology/ebrick/health"
 	"github.com/trinitytechnology/ebrick/logger"
 	"github.com/trinitytechnology/ebrick/messaging"
 	"github.com/trinitytechnology/ebrick/observability"
 	"github.com/trinitytechnology/ebrick/server"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type Options struct {
-	Name           string
-	Version        string
-	Database       *gorm.DB
-	Cache          cache.Cache
-	EventStream    messaging.CloudEventStream
-	HttpServer     server.HttpServer
-	TracerProvider *sdktrace.TracerProvider
-	Logger         *zap.Logger
+	Name     string
+	Version  string
+	Database *gorm.DB
+	// TenantDataSources resolves the connection of the tenant of requests, see config.TenancyConfig.
+	TenantDataSources *database.TenantDataSources
+	Cache             cache.Cache
+	EventStream       messaging.CloudEventStream
+	HttpServer        server.HttpServer
+	TracerProvider    *sdktrace.TracerProvider
+	MeterProvider     *sdkmetric.MeterProvider
+	Logger            *zap.Logger
+	Health            *health.Registry
+
+	// ShutdownTimeout is the deadline for draining in-flight requests and
+	// releasing resources when the application stops.
+	ShutdownTimeout time.Duration
 }
 
 type Option func(*Options)
 
+const defaultShutdownTimeout = 30 * time.Second
+
 func newOptions(opts ...Option) *Options {
 	serviceCfg := config.GetConfig().Service
+	serverCfg := config.GetConfig().Server
 
 	opt := &Options{
-		Name:           serviceCfg.Name,
-		Version:        serviceCfg.Version,
-		Database:       database.DefaultDataSource,
-		Cache:          cache.DefaultCache,
-		EventStream:    messaging.DefaultCloudEventStream,
-		HttpServer:     server.DefaultServer,
-		TracerProvider: observability.DefaultTraceProvider,
-		Logger:         logger.DefaultLogger,
+		Name:              serviceCfg.Name,
+		Version:           serviceCfg.Version,
+		Database:          database.DefaultDataSource,
+		TenantDataSources: database.DefaultTenantDataSources,
+		Cache:             cache.DefaultCache,
+		EventStream:       messaging.DefaultCloudEventStream,
+		HttpServer:        server.DefaultServer,
+		TracerProvider:    observability.DefaultTraceProvider,
+		MeterProvider:     observability.DefaultMeterProvider,
+		Logger:            logger.DefaultLogger,
+		Health:            health.DefaultRegistry,
+
+		ShutdownTimeout: serverCfg.ShutdownTimeout,
 	}
 
 	for _, o := range opts {
 		o(opt)
 	}
 
+	if opt.ShutdownTimeout <= 0 {
+		opt.ShutdownTimeout = defaultShutdownTimeout
+	}
+
 	return opt
 }
 
@@ -58,3 +82,9 @@ func GetName(name string) Option {
 		o.Name = name
 	}
 }
+
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = timeout
+	}
+}
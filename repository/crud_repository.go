@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-playground/validator/v10"
@@ -22,6 +23,24 @@ type CrudRepository[T any] interface {
 	CountWithEntity(et T) (int64, error)
 }
 
+// ContextRepository is a CrudRepository whose queries can run with a context. It is implemented
+// by the repositories of this package; see WithContext for any CrudRepository.
+type ContextRepository[T any] interface {
+	CrudRepository[T]
+	// WithContext returns a repository running its queries with ctx, so they are
+	// cancelled with it and traced as children of its span.
+	WithContext(ctx context.Context) ContextRepository[T]
+}
+
+// WithContext returns the repository running its queries with ctx if it implements
+// ContextRepository, or the repository itself otherwise.
+func WithContext[T any](repo CrudRepository[T], ctx context.Context) CrudRepository[T] {
+	if r, ok := repo.(ContextRepository[T]); ok {
+		return r.WithContext(ctx)
+	}
+	return repo
+}
+
 func NewCrudRepository[T any](db *gorm.DB) CrudRepository[T] {
 	return &crudRepository[T]{db: db}
 }
@@ -30,6 +49,10 @@ type crudRepository[T any] struct {
 	db *gorm.DB
 }
 
+func (r *crudRepository[T]) WithContext(ctx context.Context) ContextRepository[T] {
+	return &crudRepository[T]{db: r.db.WithContext(ctx)}
+}
+
 func (r *crudRepository[T]) Create(et T) (*T, error) {
 	v := validator.New()
 	if err := v.Struct(et); err != nil {
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/database"
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// NewTenantRepository returns a repository of a model embedding entity.TenantAuditEntity, scoped
+// to the tenant of its context by database.TenantPlugin. Queries must run with a context carrying
+// the tenant, see ContextRepository.WithContext and tenant.WithTenant, and fail with tenant.ErrTenantRequired otherwise.
+// Updates and deletes of rows of other tenants fail with gorm.ErrRecordNotFound.
+func NewTenantRepository[T any](db *gorm.DB) ContextRepository[T] {
+	plugin := &database.TenantPlugin{}
+	if _, ok := db.Config.Plugins[plugin.Name()]; !ok {
+		if err := db.Use(plugin); err != nil {
+			logger.DefaultLogger.Fatal("Failed to register database tenant scoping", zap.Error(err))
+		}
+	}
+	return &tenantRepository[T]{crudRepository: crudRepository[T]{db: database.RequireTenant(db)}}
+}
+
+// NewTenantRepositoryFromSources returns a tenant repository whose WithContext uses the connection
+// resolved for the tenant of the context by sources, following the configured tenancy strategy.
+// Operations fail with the error of the resolution, such as tenant.ErrTenantRequired.
+// sources must not be nil: database.DefaultTenantDataSources is nil when the database is disabled.
+func NewTenantRepositoryFromSources[T any](sources *database.TenantDataSources) ContextRepository[T] {
+	if sources == nil {
+		logger.DefaultLogger.Fatal("Tenant data sources are required, is the database enabled?")
+	}
+	r := NewTenantRepository[T](sources.Default()).(*tenantRepository[T])
+	r.sources = sources
+	return r
+}
+
+type tenantRepository[T any] struct {
+	crudRepository[T]
+	sources *database.TenantDataSources
+}
+
+func (r *tenantRepository[T]) WithContext(ctx context.Context) ContextRepository[T] {
+	db := r.db.WithContext(ctx)
+	if r.sources != nil {
+		tdb, err := r.sources.DB(ctx)
+		if err != nil {
+			db.AddError(err)
+		} else {
+			db = database.RequireTenant(tdb)
+		}
+	}
+	return &tenantRepository[T]{crudRepository: crudRepository[T]{db: db}, sources: r.sources}
+}
+
+func (r *tenantRepository[T]) Create(et T) (*T, error) {
+	r.assignTenant(&et)
+	return r.crudRepository.Create(et)
+}
+
+func (r *tenantRepository[T]) Update(et T) (*T, error) {
+	r.assignTenant(&et)
+	v := validator.New()
+	if err := v.Struct(et); err != nil {
+		return nil, err
+	}
+	result := r.db.Save(&et)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &et, result.Error
+}
+
+func (r *tenantRepository[T]) Delete(id uuid.UUID) error {
+	var et T
+	result := r.db.Delete(&et, id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// tenantEntity is implemented by entities embedding entity.TenantAuditEntity.
+type tenantEntity interface {
+	GetTenantId() uuid.UUID
+	SetTenantId(id uuid.UUID)
+}
+
+// assignTenant sets the tenant of the context on an entity without tenant, before it is validated.
+func (r *tenantRepository[T]) assignTenant(et *T) {
+	te, ok := any(et).(tenantEntity)
+	if !ok || te.GetTenantId() != uuid.Nil {
+		return
+	}
+	if id, err := tenant.IDFromContext(r.db.Statement.Context); err == nil {
+		te.SetTenantId(id)
+	}
+}
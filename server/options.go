@@ -25,14 +25,33 @@ func newOptions(opts ...Option) Options {
 	obsCfg := config.GetConfig().Observability
 	oidcCfg := config.GetConfig().Oidc
 
-	webRouter := web.InitRouter()
-
 	if oidcCfg.Enable {
 		middleware.InitOIDC(&oidcCfg)
 	}
 
+	// Middlewares are attached before any route is registered, as gin only applies them to
+	// the routes registered afterwards.
+	var middlewares []gin.HandlerFunc
+	if obsCfg.Metrics.Enable {
+		middlewares = append(middlewares, observability.MetricsMiddleware())
+	}
+
 	if obsCfg.Tracing.Enable {
-		webRouter.Use(observability.TracingMiddleware(), observability.LoggingWithTraceIDMiddleware())
+		middlewares = append(middlewares, observability.TracingMiddleware())
+	}
+
+	accessLogCfg := config.GetConfig().AccessLog
+	if accessLogCfg.Enable {
+		middlewares = append(middlewares, middleware.AccessLog(accessLogCfg))
+	} else if obsCfg.Tracing.Enable {
+		middlewares = append(middlewares, observability.LoggingWithTraceIDMiddleware())
+	}
+
+	webRouter := web.InitRouter(middlewares...)
+	if obsCfg.Metrics.Enable {
+		if path := observability.MetricsPath(obsCfg.Metrics); path != "" {
+			webRouter.GET(path, gin.WrapH(observability.MetricsHandler()))
+		}
 	}
 
 	opt := Options{
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -12,10 +15,12 @@ var DefaultServer HttpServer = NewHttpServer()
 type HttpServer interface {
 	GetRouter() *gin.Engine
 	Start() error
+	Stop(ctx context.Context) error
 }
 
 type httpServer struct {
-	opts Options
+	opts   Options
+	server *http.Server
 }
 
 // GetRouter implements HttpServer.
@@ -24,17 +29,37 @@ func (h *httpServer) GetRouter() *gin.Engine {
 }
 
 // Start implements HttpServer.
+// It blocks until the server is stopped and returns nil on a graceful shutdown.
 func (h *httpServer) Start() error {
 	// Start the Gin server
 	h.opts.Logger.Info(fmt.Sprintf("Starting HTTP Server: %d", h.opts.Port))
-	if err := h.opts.Router.Run(fmt.Sprintf(":%d", h.opts.Port)); err != nil {
-		h.opts.Logger.Fatal("Failed to start Gin server", zap.Error(err))
+	if err := h.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		h.opts.Logger.Error("Failed to start Gin server", zap.Error(err))
+		return err
 	}
 	return nil
 }
 
+// Stop implements HttpServer.
+// It stops accepting new connections and waits for in-flight requests to
+// complete until the context deadline is reached.
+func (h *httpServer) Stop(ctx context.Context) error {
+	h.opts.Logger.Info("Stopping HTTP Server")
+	if err := h.server.Shutdown(ctx); err != nil {
+		h.opts.Logger.Error("Failed to drain HTTP server", zap.Error(err))
+		return err
+	}
+	h.opts.Logger.Info("HTTP Server stopped")
+	return nil
+}
+
 func NewHttpServer(opts ...Option) HttpServer {
+	o := newOptions(opts...)
 	return &httpServer{
-		opts: newOptions(opts...),
+		opts: o,
+		server: &http.Server{
+			Addr:    fmt.Sprintf(":%d", o.Port),
+			Handler: o.Router,
+		},
 	}
 }
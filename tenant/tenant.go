@@ -0,0 +1,47 @@
+// Package tenant carries the tenant of a request through its context.
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTenantRequired  = errors.New("tenant is required")
+	ErrInvalidTenantID = errors.New("invalid tenant ID")
+	ErrUnknownTenant   = errors.New("unknown tenant")
+	ErrNotMember       = errors.New("principal is not a member of the tenant")
+)
+
+// Tenant is the tenant a request is performed for.
+type Tenant struct {
+	ID uuid.UUID
+	// Key is the value the tenant was resolved from, such as its ID or its subdomain.
+	Key string
+	// Source is the name of the resolver that found the tenant.
+	Source string
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant.
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext returns the tenant carried by ctx, if it was resolved.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(*Tenant)
+	return t, ok && t != nil
+}
+
+// IDFromContext returns the ID of the tenant carried by ctx, or ErrTenantRequired.
+func IDFromContext(ctx context.Context) (uuid.UUID, error) {
+	t, ok := FromContext(ctx)
+	if !ok {
+		return uuid.Nil, ErrTenantRequired
+	}
+	return t.ID, nil
+}
@@ -5,20 +5,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"github.com/trinitytechnology/ebrick/web/problem"
 )
 
 func GetUUIDParam(c *gin.Context, param string) (uuid.UUID, bool) {
 	p := c.Param(param)
 	pUUID, err := uuid.Parse(p)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse " + param})
+		problem.Abort(c, http.StatusBadRequest, "Failed to parse "+param)
 		return uuid.Nil, false
 	}
 	return pUUID, true
 }
 
-// Get Tenant by get "tenant_id" param return uuid.Nil if not found or error
+// Get Tenant resolved by the tenant middleware, or by get "tenant_id" param
+// return uuid.Nil if not found or error
+//
+// Deprecated: use tenant.IDFromContext, which reports a missing tenant as an error.
 func GetTenantUUID(c *gin.Context) uuid.UUID {
+	if t, ok := tenant.FromContext(c.Request.Context()); ok {
+		return t.ID
+	}
 	p := c.Param("tenant_id")
 	pUUID, err := uuid.Parse(p)
 	if err != nil {
@@ -0,0 +1,402 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Fields of the access log, see config.AccessLogConfig.
+const (
+	AccessLogStatus       = "status"
+	AccessLogMethod       = "method"
+	AccessLogPath         = "path"
+	AccessLogRoute        = "route"
+	AccessLogQuery        = "query"
+	AccessLogIP           = "ip"
+	AccessLogLatency      = "latency"
+	AccessLogUserAgent    = "user_agent"
+	AccessLogReferer      = "referer"
+	AccessLogRequestSize  = "request_size"
+	AccessLogResponseSize = "response_size"
+	AccessLogUser         = "user"
+	AccessLogTenant       = "tenant"
+	AccessLogRequestID    = "request_id"
+	AccessLogTraceID      = "trace_id"
+	AccessLogErrors       = "errors"
+
+	redactedValue         = "[REDACTED]"
+	defaultAccessLogBody  = 4096
+	defaultUserClaim      = "sub"
+	defaultTenantClaim    = "tenant_id"
+	accessLogLoggerName   = "access"
+	accessLogMessage      = "Request"
+	omittedBodyMessage    = "[omitted: body cannot be redacted]"
+	truncatedBodyEllipsis = "...[truncated]"
+)
+
+var defaultAccessLogFields = []string{
+	AccessLogStatus, AccessLogMethod, AccessLogPath, AccessLogRoute, AccessLogQuery, AccessLogIP,
+	AccessLogLatency, AccessLogUserAgent, AccessLogReferer, AccessLogRequestSize, AccessLogResponseSize,
+	AccessLogUser, AccessLogTenant, AccessLogRequestID, AccessLogTraceID, AccessLogErrors,
+}
+
+var defaultProbePaths = []string{"/health", "/ready", "/startup", "/metrics"}
+
+// Headers holding credentials are always redacted.
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Fields holding credentials are always redacted in the query and captured bodies.
+var defaultRedactFields = []string{
+	"password", "passwd", "secret", "client_secret", "token", "access_token", "refresh_token",
+	"id_token", "authorization", "api_key", "apikey", "credentials",
+}
+
+type accessLogger struct {
+	cfg           config.AccessLogConfig
+	log           *zap.Logger
+	fields        map[string]bool
+	skip          map[string]bool
+	probes        map[string]bool
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+	maxBody       int
+	probeCount    atomic.Uint64
+}
+
+// AccessLog logs every request with the fields configured in cfg, at the error level for
+// 5xx responses, warn for 4xx and info otherwise. The entries are written by the "access"
+// named logger, whose level can be changed at runtime. Credential headers, and credential
+// fields of the query and captured bodies, are always redacted; cfg.RedactFields adds to them.
+func AccessLog(cfg config.AccessLogConfig) gin.HandlerFunc {
+	return newAccessLogger(cfg, logger.Named(logger.DefaultLogger, accessLogLoggerName)).handle
+}
+
+func newAccessLogger(cfg config.AccessLogConfig, log *zap.Logger) *accessLogger {
+	a := &accessLogger{
+		cfg:           cfg,
+		log:           log,
+		fields:        toSet(cfg.Fields, false),
+		skip:          toSet(cfg.SkipPaths, false),
+		probes:        toSet(cfg.ProbePaths, false),
+		redactHeaders: toSet(append(cfg.RedactHeaders, defaultRedactHeaders...), true),
+		redactFields:  toSet(append(cfg.RedactFields, defaultRedactFields...), true),
+		maxBody:       cfg.Body.MaxSize,
+	}
+	if len(a.fields) == 0 {
+		a.fields = toSet(defaultAccessLogFields, false)
+	}
+	if len(a.probes) == 0 {
+		a.probes = toSet(defaultProbePaths, false)
+	}
+	if a.maxBody <= 0 {
+		a.maxBody = defaultAccessLogBody
+	}
+	if a.cfg.UserClaim == "" {
+		a.cfg.UserClaim = defaultUserClaim
+	}
+	if a.cfg.TenantClaim == "" {
+		a.cfg.TenantClaim = defaultTenantClaim
+	}
+	return a
+}
+
+func (a *accessLogger) handle(c *gin.Context) {
+	path := c.Request.URL.Path
+	route := c.FullPath()
+	if a.skip[path] || (route != "" && a.skip[route]) {
+		c.Next()
+		return
+	}
+
+	start := time.Now()
+	var reqBody []byte
+	var reqTruncated bool
+	if a.cfg.Body.Request && isTextual(c.ContentType()) {
+		reqBody, reqTruncated = a.captureRequestBody(c)
+	}
+	// The size of a request without Content-Length is the number of bytes read by the handlers.
+	var reqSize *countingReader
+	if c.Request.ContentLength < 0 && c.Request.Body != nil {
+		reqSize = &countingReader{ReadCloser: c.Request.Body}
+		c.Request.Body = reqSize
+	}
+	var resp *bodyWriter
+	if a.cfg.Body.Response {
+		resp = &bodyWriter{ResponseWriter: c.Writer, max: a.maxBody}
+		c.Writer = resp
+	}
+
+	c.Next()
+
+	status := c.Writer.Status()
+	if (a.probes[path] || a.probes[route]) && status < http.StatusInternalServerError && !a.sampleProbe() {
+		return
+	}
+
+	size := c.Request.ContentLength
+	if reqSize != nil {
+		size = reqSize.n
+	}
+	fields := a.requestFields(c, route, status, size, time.Since(start))
+	if reqBody != nil {
+		fields = append(fields, zap.String("request_body", a.body(reqBody, reqTruncated, c.ContentType())))
+	}
+	if resp != nil && isTextual(c.Writer.Header().Get("Content-Type")) {
+		fields = append(fields, zap.String("response_body", a.body(resp.buf.Bytes(), resp.truncated, c.Writer.Header().Get("Content-Type"))))
+	}
+
+	switch {
+	case status >= http.StatusInternalServerError:
+		a.log.Error(accessLogMessage, fields...)
+	case status >= http.StatusBadRequest:
+		a.log.Warn(accessLogMessage, fields...)
+	default:
+		a.log.Info(accessLogMessage, fields...)
+	}
+}
+
+// requestFields returns the configured fields of the request.
+func (a *accessLogger) requestFields(c *gin.Context, route string, status int, size int64, latency time.Duration) []zap.Field {
+	req := c.Request
+	fields := make([]zap.Field, 0, len(a.fields)+1)
+	add := func(name string, field func() zap.Field) {
+		if a.fields[name] {
+			fields = append(fields, field())
+		}
+	}
+	add(AccessLogStatus, func() zap.Field { return zap.Int(AccessLogStatus, status) })
+	add(AccessLogMethod, func() zap.Field { return zap.String(AccessLogMethod, req.Method) })
+	add(AccessLogPath, func() zap.Field { return zap.String(AccessLogPath, req.URL.Path) })
+	add(AccessLogRoute, func() zap.Field { return zap.String(AccessLogRoute, route) })
+	add(AccessLogQuery, func() zap.Field { return zap.String(AccessLogQuery, a.query(req.URL.RawQuery)) })
+	add(AccessLogIP, func() zap.Field { return zap.String(AccessLogIP, c.ClientIP()) })
+	add(AccessLogLatency, func() zap.Field { return zap.Duration(AccessLogLatency, latency) })
+	add(AccessLogUserAgent, func() zap.Field { return zap.String(AccessLogUserAgent, req.UserAgent()) })
+	add(AccessLogReferer, func() zap.Field { return zap.String(AccessLogReferer, req.Referer()) })
+	add(AccessLogRequestSize, func() zap.Field { return zap.Int64(AccessLogRequestSize, size) })
+	add(AccessLogResponseSize, func() zap.Field { return zap.Int(AccessLogResponseSize, max(c.Writer.Size(), 0)) })
+	add(AccessLogUser, func() zap.Field { return zap.String(AccessLogUser, claimString(c, a.cfg.UserClaim)) })
+	add(AccessLogTenant, func() zap.Field {
+		if t, ok := tenant.FromContext(req.Context()); ok {
+			return zap.String(AccessLogTenant, t.ID.String())
+		}
+		id := c.Param("tenant_id")
+		if id == "" {
+			id = claimString(c, a.cfg.TenantClaim)
+		}
+		return zap.String(AccessLogTenant, id)
+	})
+	add(AccessLogRequestID, func() zap.Field {
+		id := observability.RequestIDFromContext(req.Context())
+		if id == "" {
+			id = req.Header.Get(observability.RequestIDHeader)
+		}
+		return zap.String(AccessLogRequestID, id)
+	})
+	add(AccessLogTraceID, func() zap.Field {
+		var id string
+		if sc := trace.SpanContextFromContext(req.Context()); sc.HasTraceID() {
+			id = sc.TraceID().String()
+		}
+		return zap.String(AccessLogTraceID, id)
+	})
+	if a.fields[AccessLogErrors] && len(c.Errors) > 0 {
+		fields = append(fields, zap.Strings(AccessLogErrors, c.Errors.Errors()))
+	}
+	if len(a.cfg.Headers) > 0 {
+		headers := make(map[string]string, len(a.cfg.Headers))
+		for _, name := range a.cfg.Headers {
+			if v := req.Header.Get(name); v != "" {
+				if a.redactHeaders[strings.ToLower(name)] {
+					v = redactedValue
+				}
+				headers[name] = v
+			}
+		}
+		fields = append(fields, zap.Any("headers", headers))
+	}
+	return fields
+}
+
+// query returns the raw query with the values of the configured fields redacted. The order and
+// encoding of the other parameters are kept.
+func (a *accessLogger) query(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil {
+			key = name
+		}
+		if a.redactFields[strings.ToLower(key)] {
+			params[i] = url.QueryEscape(key) + "=" + redactedValue
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// captureRequestBody reads up to maxBody bytes of the request body and restores it for the handlers.
+func (a *accessLogger) captureRequestBody(c *gin.Context) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+	buf, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(a.maxBody)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), c.Request.Body), c.Request.Body}
+	if err != nil {
+		return nil, false
+	}
+	if len(buf) > a.maxBody {
+		return buf[:a.maxBody], true
+	}
+	return buf, false
+}
+
+// body returns the captured body with the configured fields redacted. A body that must be
+// redacted but cannot be parsed, e.g. because it was truncated, is omitted.
+func (a *accessLogger) body(data []byte, truncated bool, contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v any
+		if truncated || json.Unmarshal(data, &v) != nil {
+			return omittedBodyMessage
+		}
+		redacted, err := json.Marshal(redactJSON(v, a.redactFields))
+		if err != nil {
+			return omittedBodyMessage
+		}
+		data = redacted
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(data))
+		if truncated || err != nil {
+			return omittedBodyMessage
+		}
+		for key := range values {
+			if a.redactFields[strings.ToLower(key)] {
+				values[key] = []string{redactedValue}
+			}
+		}
+		data = []byte(values.Encode())
+	}
+	if truncated {
+		return string(data) + truncatedBodyEllipsis
+	}
+	return string(data)
+}
+
+// sampleProbe reports whether a request to a probe endpoint is logged.
+func (a *accessLogger) sampleProbe() bool {
+	if a.cfg.ProbeSampling <= 0 {
+		return false
+	}
+	return (a.probeCount.Add(1)-1)%uint64(a.cfg.ProbeSampling) == 0
+}
+
+// redactJSON replaces the values of the given keys in a decoded JSON value, at any depth.
+func redactJSON(v any, keys map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if keys[strings.ToLower(k)] {
+				v[k] = redactedValue
+			} else {
+				v[k] = redactJSON(val, keys)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val, keys)
+		}
+	}
+	return v
+}
+
+// claimString returns a string claim of the authenticated user, or an empty string.
+func claimString(c *gin.Context, name string) string {
+	claims, ok := c.Get("claims")
+	if !ok {
+		return ""
+	}
+	m, ok := claims.(map[string]any)
+	if !ok {
+		return ""
+	}
+	s, _ := m[name].(string)
+	return s
+}
+
+// isTextual reports whether a body of the content type can be logged as text.
+func isTextual(contentType string) bool {
+	return strings.Contains(contentType, "json") ||
+		strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "xml") ||
+		strings.Contains(contentType, "x-www-form-urlencoded")
+}
+
+// toSet returns the values as a set, lower-cased if fold is set.
+func toSet(values []string, fold bool) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if fold {
+			v = strings.ToLower(v)
+		}
+		set[v] = true
+	}
+	return set
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// bodyWriter keeps a copy of the first max bytes written to the response.
+type bodyWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) capture(b []byte) {
+	if room := w.max - w.buf.Len(); room < len(b) {
+		w.truncated = true
+		b = b[:max(room, 0)]
+	}
+	w.buf.Write(b)
+}
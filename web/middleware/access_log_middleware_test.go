@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// serveAccessLog serves the request through the access log and returns the logged entries.
+func serveAccessLog(t *testing.T, cfg config.AccessLogConfig, r *http.Request, status int) []observer.LoggedEntry {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newAccessLogger(cfg, zap.New(core)).handle)
+	router.Any("/*path", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(status, "application/json", body)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), r)
+	return logs.AllUntimed()
+}
+
+func TestAccessLogRedactsCredentialsByDefault(t *testing.T) {
+	body := `{"user":"alice","password":"secret-password","profile":{"ssn":"secret-ssn","api_key":"secret-key"}}`
+	r := httptest.NewRequest(http.MethodPost, "/login?token=secret-token&page=2", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	cfg := config.AccessLogConfig{RedactFields: []string{"ssn"}, Body: config.AccessLogBodyConfig{Request: true, Response: true}}
+
+	logs := serveAccessLog(t, cfg, r, http.StatusOK)
+	if len(logs) != 1 {
+		t.Fatalf("got %d entries", len(logs))
+	}
+	fields := logs[0].ContextMap()
+	for _, key := range []string{"query", "request_body", "response_body"} {
+		if v, _ := fields[key].(string); strings.Contains(v, "secret") {
+			t.Errorf("%s is not redacted: %s", key, v)
+		}
+	}
+	if got := fields["query"]; got != "token=[REDACTED]&page=2" {
+		t.Errorf("got query %v", got)
+	}
+	if got, _ := fields["request_body"].(string); !strings.Contains(got, `"user":"alice"`) {
+		t.Errorf("got request body %s", got)
+	}
+}
+
+func TestAccessLogRequestSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{"content length", false},
+		{"chunked", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("hello"))
+			if tt.chunked {
+				r.ContentLength = -1
+				r.TransferEncoding = []string{"chunked"}
+			}
+			logs := serveAccessLog(t, config.AccessLogConfig{}, r, http.StatusOK)
+			if got := logs[0].ContextMap()["request_size"]; got != int64(5) {
+				t.Errorf("got request size %v, want 5", got)
+			}
+		})
+	}
+}
+
+func TestAccessLogLevelsAndProbes(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+		level  zapcore.Level
+		logged bool
+	}{
+		{"/invoices", http.StatusOK, zapcore.InfoLevel, true},
+		{"/invoices", http.StatusNotFound, zapcore.WarnLevel, true},
+		{"/invoices", http.StatusBadGateway, zapcore.ErrorLevel, true},
+		{"/health", http.StatusOK, zapcore.InfoLevel, false},
+		{"/health", http.StatusServiceUnavailable, zapcore.ErrorLevel, true},
+		{"/skipped", http.StatusInternalServerError, zapcore.ErrorLevel, false},
+	}
+	for _, tt := range tests {
+		logs := serveAccessLog(t, config.AccessLogConfig{SkipPaths: []string{"/skipped"}},
+			httptest.NewRequest(http.MethodGet, tt.path, nil), tt.status)
+		if len(logs) == 1 != tt.logged {
+			t.Errorf("%s %d: got %d entries", tt.path, tt.status, len(logs))
+			continue
+		}
+		if tt.logged && logs[0].Level != tt.level {
+			t.Errorf("%s %d: got level %s, want %s", tt.path, tt.status, logs[0].Level, tt.level)
+		}
+	}
+}
@@ -2,65 +2,70 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"strings"
 
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/auth"
 	"github.com/trinitytechnology/ebrick/config"
 	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/web/problem"
 	"go.uber.org/zap"
 )
 
-var verifier *oidc.IDTokenVerifier
-var provider *oidc.Provider
+var authenticator *auth.Authenticator
 
+// InitOIDC creates the authenticator used by OIDCAuthMiddleware, stopping the application
+// if an issuer cannot be discovered.
 func InitOIDC(cfg *config.OidcConfig) {
 	logger := logger.DefaultLogger
 	if cfg.Enable {
-		logger.Info("Setting OIDC...", zap.String("issuer", cfg.Issuer))
-		ctx := context.Background()
+		logger.Info("Setting OIDC...", zap.String("issuer", cfg.Issuer), zap.Int("issuers", len(cfg.Issuers)))
 		var err error
-		provider, err = oidc.NewProvider(ctx, cfg.Issuer)
+		authenticator, err = auth.NewAuthenticator(context.Background(), *cfg)
 		if err != nil {
-			panic("Failed to get provider: " + err.Error())
+			logger.Fatal("Invalid OIDC configuration", zap.Error(err))
 		}
-		verifier = provider.Verifier(&oidc.Config{
-			ClientID:          cfg.ClientId,
-			SkipClientIDCheck: true,
-			SkipExpiryCheck:   false,
-			SkipIssuerCheck:   false,
-		})
 	}
 }
 
-// OIDCAuthMiddleware adds OIDC authentication to the request context
+// OIDCAuthMiddleware authenticates the request with the bearer access token of its
+// Authorization header. The principal of the token is stored in the request context, see
+// GetPrincipal, and its claims under the "claims" key of the gin context.
 func OIDCAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		cfg := config.GetConfig().Oidc
 		if cfg.Enable {
-			ctx := context.Background()
-			tokenString := c.GetHeader("Authorization")
-			if tokenString == "" {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-				c.Abort()
-				return
-			}
-			tokenString = strings.Trim(tokenString, "Bearer")
-			idToken, err := verifier.Verify(ctx, tokenString)
+			principal, err := authenticator.Authenticate(c.Request.Context(), c.GetHeader("Authorization"))
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-				c.Abort()
+				observability.LoggerWithTraceID(c.Request.Context()).Debug("Authentication failed", zap.Error(err))
+				abortUnauthorized(c, err)
 				return
 			}
-			var claims map[string]any
-			if err := idToken.Claims(&claims); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse claims"})
-				c.Abort()
-				return
-			}
-			c.Set("claims", claims)
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+			c.Set("claims", principal.Claims)
 		}
 		c.Next()
 	}
 }
+
+// GetPrincipal returns the principal authenticated by OIDCAuthMiddleware.
+func GetPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	return auth.PrincipalFromContext(c.Request.Context())
+}
+
+// abortUnauthorized responds with a 401 problem and the RFC 6750 challenge for the error.
+func abortUnauthorized(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrMissingToken), errors.Is(err, auth.ErrInvalidScheme):
+		c.Header("WWW-Authenticate", `Bearer`)
+		problem.Abort(c, http.StatusUnauthorized, "Bearer token is required")
+	case errors.Is(err, auth.ErrTokenExpired):
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token", error_description="The token is expired"`)
+		problem.Abort(c, http.StatusUnauthorized, "Token is expired")
+	default:
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		problem.Abort(c, http.StatusUnauthorized, "Invalid token")
+	}
+}
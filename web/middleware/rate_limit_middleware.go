@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/web/problem"
+)
+
+// RateLimit limits the requests handled by the route group to rate per second,
+// allowing bursts of up to burst requests. Requests over the limit get 429.
+// The rate must be positive, otherwise no request is allowed once the burst is spent.
+func RateLimit(rate float64, burst int) gin.HandlerFunc {
+	return rateLimit(rate, burst, time.Now)
+}
+
+func rateLimit(rate float64, burst int, now func() time.Time) gin.HandlerFunc {
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	var (
+		mu     sync.Mutex
+		tokens = float64(burst)
+		last   = now()
+	)
+	return func(c *gin.Context) {
+		mu.Lock()
+		t := now()
+		tokens = math.Min(float64(burst), tokens+t.Sub(last).Seconds()*rate)
+		last = t
+		allowed := tokens >= 1
+		if allowed {
+			tokens--
+		}
+		mu.Unlock()
+
+		if !allowed {
+			problem.Abort(c, http.StatusTooManyRequests, "Too many requests")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitRefills(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Unix(1_700_000_000, 0)
+	router := gin.New()
+	router.GET("/", rateLimit(2, 1, func() time.Time { return now }), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	request := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	if code := request(); code != http.StatusNoContent {
+		t.Fatalf("got %d for the first request", code)
+	}
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want %d once the burst is spent", code, http.StatusTooManyRequests)
+	}
+	// A token is added every half second at 2 requests per second.
+	now = now.Add(250 * time.Millisecond)
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want %d before a token is added", code, http.StatusTooManyRequests)
+	}
+	now = now.Add(250 * time.Millisecond)
+	if code := request(); code != http.StatusNoContent {
+		t.Fatalf("got %d once a token is added", code)
+	}
+	// The bucket holds no more than the burst.
+	now = now.Add(time.Hour)
+	request()
+	if code := request(); code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want %d beyond the burst", code, http.StatusTooManyRequests)
+	}
+}
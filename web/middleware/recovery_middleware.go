@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/web/problem"
+	"go.uber.org/zap"
+)
+
+// Recovery recovers from panics in the handlers, logs them with their stack trace, request ID
+// and trace ID, and responds with an internal server error problem unless the response was
+// already written. Panics caused by the client closing the connection only abort the request.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			log := observability.LoggerWithTraceID(c.Request.Context())
+			if err, ok := r.(error); ok && isBrokenPipe(err) {
+				log.Warn("Connection closed by client", zap.String("path", c.Request.URL.Path), zap.Error(err))
+				c.Error(err)
+				c.Abort()
+				return
+			}
+			log.Error("Recovered from panic",
+				zap.Any("panic", r),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Stack("stack"),
+			)
+			c.Error(fmt.Errorf("panic: %v", r))
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			problem.Abort(c, http.StatusInternalServerError, "")
+		}()
+		c.Next()
+	}
+}
+
+// ErrorHandler renders the last error added to the context with c.Error as a problem,
+// if the handlers did not write a response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem.Render(c, problem.FromError(c.Errors.Last().Err))
+	}
+}
+
+// isBrokenPipe reports whether err is caused by the client closing the connection.
+func isBrokenPipe(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var sysErr *os.SyscallError
+	return errors.As(opErr, &sysErr) && (errors.Is(sysErr, syscall.EPIPE) || errors.Is(sysErr, syscall.ECONNRESET))
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/auth"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/web/problem"
+	"go.uber.org/zap"
+)
+
+// Policy decides whether the principal authenticated by OIDCAuthMiddleware may perform the
+// request, for rules depending on the requested resource. It returns nil to allow the request,
+// an error wrapping auth.ErrForbidden to deny it, or any other error if no decision could be made.
+type Policy func(c *gin.Context, principal *auth.Principal) error
+
+// RequireAuthenticated allows the request only if it carries an authenticated principal.
+func RequireAuthenticated() gin.HandlerFunc {
+	return RequirePolicy(func(c *gin.Context, principal *auth.Principal) error {
+		return nil
+	})
+}
+
+// RequireRole allows the request only if the principal has the role.
+func RequireRole(role string) gin.HandlerFunc {
+	return RequireRoles(role)
+}
+
+// RequireRoles allows the request only if the principal has all the roles.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return RequirePolicy(func(c *gin.Context, principal *auth.Principal) error {
+		for _, role := range roles {
+			if !principal.HasRole(role) {
+				return fmt.Errorf("%w: missing role %s", auth.ErrForbidden, role)
+			}
+		}
+		return nil
+	})
+}
+
+// RequireAnyRole allows the request only if the principal has at least one of the roles.
+func RequireAnyRole(roles ...string) gin.HandlerFunc {
+	return RequirePolicy(func(c *gin.Context, principal *auth.Principal) error {
+		if !slices.ContainsFunc(roles, principal.HasRole) {
+			return fmt.Errorf("%w: missing one of roles %v", auth.ErrForbidden, roles)
+		}
+		return nil
+	})
+}
+
+// RequireScopes allows the request only if the principal has been granted all the scopes.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return RequirePolicy(func(c *gin.Context, principal *auth.Principal) error {
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				return fmt.Errorf("%w: missing scope %s", auth.ErrForbidden, scope)
+			}
+		}
+		return nil
+	})
+}
+
+// RequirePolicy allows the request only if the policy allows it. Unauthenticated requests get
+// 401, denied requests 403, and policy failures the problem describing their error.
+func RequirePolicy(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			problem.Abort(c, http.StatusUnauthorized, "Authentication is required")
+			return
+		}
+		if err := policy(c, principal); err != nil {
+			if errors.Is(err, auth.ErrForbidden) {
+				observability.LoggerWithTraceID(c.Request.Context()).Debug("Access denied",
+					zap.String("subject", principal.Subject), zap.String("path", c.FullPath()), zap.Error(err))
+				c.Error(err)
+				problem.Abort(c, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+			problem.AbortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/auth"
+)
+
+// serveAs serves a request authenticated as principal, or anonymous if it is nil, through handler.
+func serveAs(principal *auth.Principal, handler gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", handler, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if principal != nil {
+		r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRequireRolesAndScopes(t *testing.T) {
+	principal := &auth.Principal{Subject: "user-1", Roles: []string{"reader", "writer"}, Scopes: []string{"invoices:read"}}
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		handler   gin.HandlerFunc
+		want      int
+	}{
+		{"role", principal, RequireRole("reader"), http.StatusNoContent},
+		{"all roles", principal, RequireRoles("reader", "writer"), http.StatusNoContent},
+		{"missing role", principal, RequireRoles("reader", "admin"), http.StatusForbidden},
+		{"any role", principal, RequireAnyRole("admin", "writer"), http.StatusNoContent},
+		{"none of the roles", principal, RequireAnyRole("admin", "owner"), http.StatusForbidden},
+		{"scope", principal, RequireScopes("invoices:read"), http.StatusNoContent},
+		{"missing scope", principal, RequireScopes("invoices:read", "invoices:write"), http.StatusForbidden},
+		{"role as scope", principal, RequireScopes("reader"), http.StatusForbidden},
+		{"anonymous", nil, RequireRoles("reader"), http.StatusUnauthorized},
+		{"anonymous with scope", nil, RequireScopes("invoices:read"), http.StatusUnauthorized},
+		{"authenticated", principal, RequireAuthenticated(), http.StatusNoContent},
+		{"policy error", principal, RequirePolicy(func(*gin.Context, *auth.Principal) error {
+			return errors.New("policy store is unavailable")
+		}), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveAs(tt.principal, tt.handler); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
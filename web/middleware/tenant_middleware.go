@@ -1,28 +1,268 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/auth"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/logger"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/tenant"
+	"github.com/trinitytechnology/ebrick/web/problem"
+	"go.uber.org/zap"
 )
 
+// Tenant resolver names, see config.TenantConfig.
+const (
+	TenantFromPath      = "path"
+	TenantFromHeader    = "header"
+	TenantFromClaim     = "claim"
+	TenantFromSubdomain = "subdomain"
+
+	defaultTenantParam  = "tenant_id"
+	defaultTenantHeader = "X-Tenant-ID"
+)
+
+var defaultMembershipClaims = []string{"tenant_id", "tenants"}
+
+// TenantResolver returns the tenant key carried by a request, or an empty string.
+type TenantResolver func(c *gin.Context) string
+
+// TenantLookup returns the ID of the tenant identified by a key, or an error wrapping
+// tenant.ErrUnknownTenant or tenant.ErrInvalidTenantID. The default lookup parses the key as a UUID.
+type TenantLookup func(ctx context.Context, key string) (uuid.UUID, error)
+
+// TenantMembership reports whether the principal belongs to the tenant.
+type TenantMembership func(ctx context.Context, principal *auth.Principal, t *tenant.Tenant) (bool, error)
+
+type tenantResolver struct {
+	name    string
+	resolve TenantResolver
+}
+
+// tenantOptions configures ResolveTenant beyond the configuration.
+type tenantOptions struct {
+	resolvers  []tenantResolver
+	lookup     TenantLookup
+	membership TenantMembership
+}
+
+type TenantOption func(*tenantOptions)
+
+// WithTenantResolver adds a custom resolver, tried after the configured ones.
+func WithTenantResolver(name string, resolve TenantResolver) TenantOption {
+	return func(o *tenantOptions) {
+		o.resolvers = append(o.resolvers, tenantResolver{name, resolve})
+	}
+}
+
+// WithTenantLookup sets how tenant keys, such as subdomains, are mapped to tenant IDs.
+func WithTenantLookup(lookup TenantLookup) TenantOption {
+	return func(o *tenantOptions) {
+		o.lookup = lookup
+	}
+}
+
+// WithTenantMembership sets how the membership of principals is verified, replacing the
+// check of the membership claims.
+func WithTenantMembership(membership TenantMembership) TenantOption {
+	return func(o *tenantOptions) {
+		o.membership = membership
+	}
+}
+
+// ResolveTenant resolves the tenant of the request with the resolvers of the configuration,
+// verifies that the principal authenticated by OIDCAuthMiddleware belongs to it and stores
+// it in the request context, see tenant.FromContext. It must run after OIDCAuthMiddleware:
+// unauthenticated requests get 401 unless cfg.AllowAnonymous is set. Requests without a valid
+// tenant get 400, unknown tenants 404 and principals outside the tenant 403.
+func ResolveTenant(cfg config.TenantConfig, opts ...TenantOption) gin.HandlerFunc {
+	o := tenantOptions{
+		lookup:     parseTenantID,
+		membership: claimMembership(cfg),
+	}
+	names := cfg.Resolvers
+	if len(names) == 0 {
+		names = []string{TenantFromPath}
+	}
+	for _, name := range names {
+		resolve, err := newTenantResolver(name, cfg)
+		if err != nil {
+			logger.DefaultLogger.Fatal("Invalid tenant configuration", zap.Error(err))
+		}
+		o.resolvers = append(o.resolvers, tenantResolver{name, resolve})
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		// The tenant sent by anonymous callers cannot be verified.
+		principal, authenticated := GetPrincipal(c)
+		if !authenticated && !cfg.AllowAnonymous {
+			c.Header("WWW-Authenticate", `Bearer`)
+			problem.Abort(c, http.StatusUnauthorized, "Authentication is required")
+			return
+		}
+
+		var t *tenant.Tenant
+		for _, r := range o.resolvers {
+			if key := r.resolve(c); key != "" {
+				t = &tenant.Tenant{Key: key, Source: r.name}
+				break
+			}
+		}
+		if t == nil {
+			if cfg.Optional {
+				c.Next()
+				return
+			}
+			problem.Abort(c, http.StatusBadRequest, "Tenant ID is required")
+			return
+		}
+
+		id, err := o.lookup(ctx, t.Key)
+		if err != nil {
+			switch {
+			case errors.Is(err, tenant.ErrUnknownTenant):
+				problem.Abort(c, http.StatusNotFound, "Unknown tenant")
+			case errors.Is(err, tenant.ErrInvalidTenantID):
+				problem.Abort(c, http.StatusBadRequest, "Invalid Tenant ID")
+			default:
+				problem.AbortWithError(c, err)
+			}
+			return
+		}
+		t.ID = id
+
+		if authenticated {
+			member, err := o.membership(ctx, principal, t)
+			if err != nil {
+				problem.AbortWithError(c, err)
+				return
+			}
+			if !member {
+				observability.LoggerWithTraceID(ctx).Debug("Access to tenant denied",
+					zap.String("subject", principal.Subject), zap.String("tenant", t.ID.String()))
+				c.Error(tenant.ErrNotMember)
+				problem.Abort(c, http.StatusForbidden, "Access to the tenant is denied")
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithTenant(ctx, t))
+		c.Next()
+	}
+}
+
+// GetTenant returns the tenant resolved by ResolveTenant.
+func GetTenant(c *gin.Context) (*tenant.Tenant, bool) {
+	return tenant.FromContext(c.Request.Context())
+}
+
+// newTenantResolver returns the named resolver of the configuration.
+func newTenantResolver(name string, cfg config.TenantConfig) (TenantResolver, error) {
+	switch name {
+	case TenantFromPath:
+		param := cfg.PathParam
+		if param == "" {
+			param = defaultTenantParam
+		}
+		return func(c *gin.Context) string {
+			return c.Param(param)
+		}, nil
+	case TenantFromHeader:
+		header := cfg.Header
+		if header == "" {
+			header = defaultTenantHeader
+		}
+		return func(c *gin.Context) string {
+			return strings.TrimSpace(c.GetHeader(header))
+		}, nil
+	case TenantFromClaim:
+		claim := cfg.Claim
+		if claim == "" {
+			claim = defaultTenantParam
+		}
+		return func(c *gin.Context) string {
+			if principal, ok := GetPrincipal(c); ok {
+				if values := principal.ClaimValues(claim); len(values) == 1 {
+					return values[0]
+				}
+			}
+			return ""
+		}, nil
+	case TenantFromSubdomain:
+		domain := "." + strings.Trim(strings.ToLower(cfg.Domain), ".")
+		if domain == "." {
+			return nil, errors.New("tenant domain is required by the subdomain resolver")
+		}
+		return func(c *gin.Context) string {
+			host := strings.ToLower(c.Request.Host)
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			sub, ok := strings.CutSuffix(host, domain)
+			if !ok || strings.Contains(sub, ".") {
+				return ""
+			}
+			return sub
+		}, nil
+	}
+	return nil, errors.New("unknown tenant resolver: " + name)
+}
+
+// parseTenantID is the default TenantLookup, tenants are identified by their UUID.
+func parseTenantID(_ context.Context, key string) (uuid.UUID, error) {
+	id, err := uuid.Parse(key)
+	if err != nil {
+		return uuid.Nil, tenant.ErrInvalidTenantID
+	}
+	return id, nil
+}
+
+// claimMembership is the default TenantMembership, the tenant must be listed in one of
+// the membership claims of the principal, by ID or by key.
+func claimMembership(cfg config.TenantConfig) TenantMembership {
+	claims := cfg.MembershipClaims
+	if len(claims) == 0 {
+		claims = defaultMembershipClaims
+	}
+	return func(_ context.Context, principal *auth.Principal, t *tenant.Tenant) (bool, error) {
+		for _, claim := range claims {
+			values := principal.ClaimValues(claim)
+			if slices.Contains(values, t.ID.String()) || slices.Contains(values, t.Key) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
 // ValidateTenantID validates the tenant ID in the request
+//
+// Deprecated: use ResolveTenant, which also checks the membership of the principal.
 func ValidateTenantID() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tenantId := ctx.Param("tenant_id")
 
 		if tenantId == "" {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Tenant ID is required"})
-			ctx.Abort()
+			problem.Abort(ctx, http.StatusBadRequest, "Tenant ID is required")
 			return
 		}
 
 		// Validate UUID format
 		_, err := uuid.Parse(tenantId)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Tenant ID"})
-			ctx.Abort()
+			problem.Abort(ctx, http.StatusBadRequest, "Invalid Tenant ID")
 			return
 		}
 		ctx.Next()
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trinitytechnology/ebrick/auth"
+	"github.com/trinitytechnology/ebrick/config"
+	"github.com/trinitytechnology/ebrick/tenant"
+)
+
+// tenantRequest is a request served through ResolveTenant.
+type tenantRequest struct {
+	path      string
+	host      string
+	header    string
+	principal *auth.Principal
+}
+
+// serveTenant serves req through ResolveTenant and returns the status with the resolved tenant.
+func serveTenant(cfg config.TenantConfig, req tenantRequest, opts ...TenantOption) (int, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		t, ok := GetTenant(c)
+		if !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, "%s %s %s", t.Source, t.Key, t.ID)
+	}
+	resolve := ResolveTenant(cfg, opts...)
+	router.GET("/tenants/:tenant_id/orders", resolve, handler)
+	router.GET("/orders", resolve, handler)
+
+	r := httptest.NewRequest(http.MethodGet, req.path, nil)
+	if req.host != "" {
+		r.Host = req.host
+	}
+	if req.header != "" {
+		r.Header.Set(defaultTenantHeader, req.header)
+	}
+	if req.principal != nil {
+		r = r.WithContext(auth.WithPrincipal(r.Context(), req.principal))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code, w.Body.String()
+}
+
+func TestResolveTenant(t *testing.T) {
+	member, other := uuid.New(), uuid.New()
+	principal := &auth.Principal{Subject: "user-1", Claims: map[string]any{
+		"tenant_id": member.String(),
+		"tenants":   []any{"acme"},
+	}}
+	outsider := &auth.Principal{Subject: "user-2", Claims: map[string]any{"tenant_id": other.String()}}
+	// acme is the only tenant known by key.
+	lookup := WithTenantLookup(func(_ context.Context, key string) (uuid.UUID, error) {
+		if id, err := uuid.Parse(key); err == nil {
+			return id, nil
+		}
+		if key == "acme" {
+			return member, nil
+		}
+		return uuid.Nil, fmt.Errorf("%w: %s", tenant.ErrUnknownTenant, key)
+	})
+	all := config.TenantConfig{Resolvers: []string{TenantFromPath, TenantFromHeader, TenantFromSubdomain, TenantFromClaim}, Domain: "example.com"}
+
+	tests := []struct {
+		name       string
+		cfg        config.TenantConfig
+		req        tenantRequest
+		wantStatus int
+		wantTenant string
+	}{
+		{"path", config.TenantConfig{}, tenantRequest{path: "/tenants/" + member.String() + "/orders", principal: principal},
+			http.StatusOK, "path " + member.String() + " " + member.String()},
+		{"header", all, tenantRequest{path: "/orders", header: member.String(), principal: principal},
+			http.StatusOK, "header " + member.String() + " " + member.String()},
+		{"subdomain", all, tenantRequest{path: "/orders", host: "ACME.example.com:8080", principal: principal},
+			http.StatusOK, "subdomain acme " + member.String()},
+		{"claim", all, tenantRequest{path: "/orders", host: "example.com", principal: principal},
+			http.StatusOK, "claim " + member.String() + " " + member.String()},
+		{"resolver order", all, tenantRequest{path: "/tenants/" + member.String() + "/orders", header: other.String(), principal: principal},
+			http.StatusOK, "path " + member.String() + " " + member.String()},
+		{"nested subdomain", config.TenantConfig{Resolvers: []string{TenantFromSubdomain}, Domain: "example.com"},
+			tenantRequest{path: "/orders", host: "a.acme.example.com", principal: principal}, http.StatusBadRequest, ""},
+		{"other domain", config.TenantConfig{Resolvers: []string{TenantFromSubdomain}, Domain: "example.com"},
+			tenantRequest{path: "/orders", host: "acme.example.org", principal: principal}, http.StatusBadRequest, ""},
+		{"not a member", all, tenantRequest{path: "/orders", header: member.String(), principal: outsider}, http.StatusForbidden, ""},
+		{"member by key", all, tenantRequest{path: "/orders", host: "acme.example.com", principal: outsider}, http.StatusForbidden, ""},
+		{"unknown tenant", all, tenantRequest{path: "/orders", host: "globex.example.com", principal: principal}, http.StatusNotFound, ""},
+		{"header key", config.TenantConfig{Resolvers: []string{TenantFromHeader}}, tenantRequest{path: "/orders", header: "acme", principal: principal},
+			http.StatusOK, "header acme " + member.String()},
+		{"missing tenant", config.TenantConfig{Resolvers: []string{TenantFromHeader}}, tenantRequest{path: "/orders", principal: principal}, http.StatusBadRequest, ""},
+		{"optional tenant", config.TenantConfig{Resolvers: []string{TenantFromHeader}, Optional: true}, tenantRequest{path: "/orders", principal: principal}, http.StatusOK, "none"},
+		{"anonymous", all, tenantRequest{path: "/orders", header: member.String()}, http.StatusUnauthorized, ""},
+		{"allowed anonymous", config.TenantConfig{Resolvers: []string{TenantFromHeader}, AllowAnonymous: true}, tenantRequest{path: "/orders", header: other.String()},
+			http.StatusOK, "header " + other.String() + " " + other.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serveTenant(tt.cfg, tt.req, lookup)
+			if status != tt.wantStatus {
+				t.Fatalf("got %d, want %d: %s", status, tt.wantStatus, body)
+			}
+			if tt.wantTenant != "" && body != tt.wantTenant {
+				t.Errorf("got tenant %q, want %q", body, tt.wantTenant)
+			}
+		})
+	}
+}
+
+func TestResolveTenantDefaultLookup(t *testing.T) {
+	principal := &auth.Principal{Subject: "user-1", Claims: map[string]any{"tenant_id": "acme"}}
+	cfg := config.TenantConfig{Resolvers: []string{TenantFromHeader}}
+	if status, _ := serveTenant(cfg, tenantRequest{path: "/orders", header: "acme", principal: principal}); status != http.StatusBadRequest {
+		t.Errorf("got %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestResolveTenantMembership(t *testing.T) {
+	id := uuid.New()
+	principal := &auth.Principal{Subject: "user-1", Claims: map[string]any{"org": id.String()}}
+	req := tenantRequest{path: "/orders", header: id.String(), principal: principal}
+
+	cfg := config.TenantConfig{Resolvers: []string{TenantFromHeader}, MembershipClaims: []string{"org"}}
+	if status, _ := serveTenant(cfg, req); status != http.StatusOK {
+		t.Errorf("membership claims: got %d, want %d", status, http.StatusOK)
+	}
+	denied := WithTenantMembership(func(context.Context, *auth.Principal, *tenant.Tenant) (bool, error) {
+		return false, nil
+	})
+	if status, _ := serveTenant(cfg, req, denied); status != http.StatusForbidden {
+		t.Errorf("custom membership: got %d, want %d", status, http.StatusForbidden)
+	}
+	failed := WithTenantMembership(func(context.Context, *auth.Principal, *tenant.Tenant) (bool, error) {
+		return false, fmt.Errorf("membership store is unavailable")
+	})
+	if status, _ := serveTenant(cfg, req, failed); status != http.StatusInternalServerError {
+		t.Errorf("membership error: got %d, want %d", status, http.StatusInternalServerError)
+	}
+}
+
+func TestNewTenantResolverInvalid(t *testing.T) {
+	for _, name := range []string{TenantFromSubdomain, "cookie"} {
+		if _, err := newTenantResolver(name, config.TenantConfig{}); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
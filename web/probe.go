@@ -4,18 +4,34 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/health"
 )
 
 // setupProbeRoute sets up the probe routes for the application
-func setupProbeRoute(router *gin.Engine) {
+func setupProbeRoute(router *gin.Engine, registry *health.Registry) {
 
-	// Health Check Endpoint
+	// Liveness Check Endpoint, the process is able to serve requests
 	router.GET("/health", func(c *gin.Context) {
-		c.Status(http.StatusOK)
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
 	})
 
-	// Readiness Check Endpoint
+	// Readiness Check Endpoint, the application has started and its critical dependencies are healthy
 	router.GET("/ready", func(c *gin.Context) {
-		c.Status(http.StatusOK)
+		ready, report := registry.Ready(c.Request.Context())
+		if !ready {
+			report.Status = health.StatusDown
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
+	// Startup Check Endpoint, all modules have been loaded and started
+	router.GET("/startup", func(c *gin.Context) {
+		if !registry.Started() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusDown})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
 	})
 }
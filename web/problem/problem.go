@@ -0,0 +1,168 @@
+// Package problem renders errors as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	apperrors "github.com/trinitytechnology/ebrick/errors"
+	"gorm.io/gorm"
+)
+
+// ContentType is the media type of problem details responses.
+const ContentType = "application/problem+json"
+
+// DefaultType is the problem type of problems only described by their status code.
+const DefaultType = "about:blank"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// InvalidParams lists the fields of the request that failed validation.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam describes a request field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error implements error.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// New returns a problem with the status code and detail.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   DefaultType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// mapping is the status code of an error and of the errors wrapping it.
+type mapping struct {
+	err    error
+	status int
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = []mapping{
+		{apperrors.ErrUnauthorized, http.StatusUnauthorized},
+		{apperrors.ErrInvalidOrExpiredToken, http.StatusUnauthorized},
+		{apperrors.ErrDuplicated, http.StatusConflict},
+		{apperrors.ErrAlreadyExisted, http.StatusConflict},
+		{apperrors.ErrNotExisted, http.StatusNotFound},
+		{apperrors.ErrValidationCheck, http.StatusUnprocessableEntity},
+		{apperrors.ErrInvalidInput, http.StatusBadRequest},
+		{apperrors.ErrNotSupportEventType, http.StatusBadRequest},
+		{apperrors.ErrNotSupportEventSource, http.StatusBadRequest},
+		{gorm.ErrRecordNotFound, http.StatusNotFound},
+		{gorm.ErrDuplicatedKey, http.StatusConflict},
+	}
+)
+
+// Register maps an error, and the errors wrapping it, to a status code, so modules can
+// have their own sentinel errors rendered by FromError. Later registrations take precedence.
+func Register(err error, status int) {
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+	mappings = append(mappings, mapping{err, status})
+}
+
+// FromError returns the problem describing err. Registered errors get their status code and
+// message, validation errors list the invalid fields, and other errors are internal server
+// errors whose message is not disclosed.
+func FromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p = New(http.StatusUnprocessableEntity, apperrors.ErrValidationCheck.Error())
+		for _, fe := range validationErrs {
+			p.InvalidParams = append(p.InvalidParams, InvalidParam{Name: fe.Namespace(), Reason: fe.Error()})
+		}
+		return p
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return New(http.StatusBadRequest, apperrors.ErrInvalidInput.Error())
+	}
+
+	if status, target := statusOf(err); target != nil {
+		return New(status, target.Error())
+	}
+	return New(http.StatusInternalServerError, "")
+}
+
+// statusOf returns the status code registered for err and the registered error it matches.
+func statusOf(err error) (int, error) {
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+	for i := len(mappings) - 1; i >= 0; i-- {
+		if errors.Is(err, mappings[i].err) {
+			return mappings[i].status, mappings[i].err
+		}
+	}
+	return 0, nil
+}
+
+// Render writes the problem as the response, with the request path as its instance.
+func Render(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		rendered := *p
+		rendered.Instance = c.Request.URL.Path
+		p = &rendered
+	}
+	c.Render(p.Status, problemRender{p})
+}
+
+// Abort writes a problem with the status code and detail and stops the handler chain.
+func Abort(c *gin.Context, status int, detail string) {
+	Render(c, New(status, detail))
+	c.Abort()
+}
+
+// AbortWithError writes the problem describing err, records err on the context and stops
+// the handler chain.
+func AbortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	Render(c, FromError(err))
+	c.Abort()
+}
+
+// problemRender renders a problem as JSON with the problem details content type.
+type problemRender struct {
+	problem *Problem
+}
+
+// Render implements render.Render.
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.problem)
+}
+
+// WriteContentType implements render.Render.
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ContentType)
+}
@@ -2,14 +2,24 @@ package web
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/trinitytechnology/ebrick/health"
+	"github.com/trinitytechnology/ebrick/observability"
+	"github.com/trinitytechnology/ebrick/web/middleware"
 )
 
-func InitRouter() *gin.Engine {
+// InitRouter creates the router with the given middlewares, attached after the request ID
+// middleware and before the probe routes are registered, so that they also run for probes.
+// The recovery and error handler middlewares are attached after them, so that every route
+// renders panics and errors as problems, and panics are still measured, traced and logged.
+func InitRouter(middlewares ...gin.HandlerFunc) *gin.Engine {
 	// Set Gin to release mode
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.New()
+	router.Use(observability.RequestIDMiddleware())
+	router.Use(middlewares...)
+	router.Use(middleware.Recovery(), middleware.ErrorHandler())
 
-	setupProbeRoute(router)
+	setupProbeRoute(router, health.DefaultRegistry)
 	return router
 }